@@ -6,6 +6,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"uk.ac.bris.cs/gameoflife/util"
 )
@@ -19,6 +20,13 @@ const QuittingEvent = 4
 const PauseEvent = 5
 const ExecutingEvent = 6
 const TurnCompleteEvent = 7
+const RejectedEvent = 8
+const WorldEvent = 9
+const ThroughputEvent = 10
+const SpeedEvent = 11
+const BreakpointHitEvent = 12
+const StabilisedEvent = 13
+const WorldAtEvent = 14
 
 /////////////////
 
@@ -32,8 +40,27 @@ type distributorChannels struct {
 	sdlKeyPresses <-chan rune
 }
 
+// inputPath returns the file the io goroutine should load the initial world from.
+func inputPath(p Params) string {
+	if p.InputFile != "" {
+		return p.InputFile
+	}
+	return fmt.Sprintf("images/%vx%v.pgm", p.ImageWidth, p.ImageHeight)
+}
+
+// snapshotName expands p.FilenameTemplate for a snapshot of the world after the given turn.
+func snapshotName(p Params, turn int) string {
+	return strings.NewReplacer(
+		"{width}", strconv.Itoa(p.ImageWidth),
+		"{height}", strconv.Itoa(p.ImageHeight),
+		"{turn}", strconv.Itoa(turn),
+		"{time}", time.Now().Format("20060102-150405"),
+		"{session}", p.SessionID,
+	).Replace(p.FilenameTemplate)
+}
+
 func writePgm(p Params, c distributorChannels, turn int, world [][]byte) {
-	fileName := fmt.Sprintf("%vx%vx%v", p.ImageWidth, p.ImageHeight, p.Turns)
+	fileName := snapshotName(p, turn)
 	c.ioCommand <- 0
 	c.ioFilename <- fileName
 	for x := 0; x < p.ImageHeight; x++ {
@@ -41,14 +68,20 @@ func writePgm(p Params, c distributorChannels, turn int, world [][]byte) {
 			c.ioOutput <- world[y][x]
 		}
 	}
+
+	// The image is only indexed once the io goroutine has written and closed it.
+	c.ioCommand <- ioCheckIdle
+	<-c.ioIdle
+	writeIndexEntry(p, turn, fileName, util.WorldHash(world))
 	c.events <- ImageOutputComplete{turn, fileName}
 }
 
 func getInitialWorld(p Params, c distributorChannels) [][]byte {
 
-	initialWorld := make([][]byte, p.ImageHeight)
+	// The world is indexed as world[X][Y], so it holds ImageWidth columns of ImageHeight cells.
+	initialWorld := make([][]byte, p.ImageWidth)
 	for i := range initialWorld {
-		initialWorld[i] = make([]byte, p.ImageWidth)
+		initialWorld[i] = make([]byte, p.ImageHeight)
 	}
 
 	var aliveCells []util.Cell
@@ -75,7 +108,9 @@ func getInitialWorld(p Params, c distributorChannels) [][]byte {
 	return initialWorld
 }
 
-func closeProgramm(c distributorChannels, turn int, done chan<- bool) {
+func closeProgramm(c distributorChannels, rec *recorder, turn int, done chan<- bool) {
+
+	rec.stop(c, turn)
 
 	// Make sure that the Io has finished any output before exiting.
 
@@ -88,16 +123,64 @@ func closeProgramm(c distributorChannels, turn int, done chan<- bool) {
 	close(c.events)
 }
 
-func sendSdlInput(conn *net.Conn, c distributorChannels, done <-chan bool) {
+// prompts are what the keys that are followed by typed text ask for.
+var prompts = map[rune]string{
+	'a': "Advance by how many turns? ",
+	'b': "Add a breakpoint: ",
+	'j': "Jump back how many turns? ",
+	'w': "Save the world at which turn? ",
+}
+
+// sendSdlInput forwards key presses to the server.
+// 'a' prompts for a number of turns, typed as digits and ended with enter, after which the server pauses,
+// 'j' for a number of turns to rewind by, 'w' for a past turn whose world to save and 'b' for a breakpoint to add. Any key that cannot be part of the answer cancels the prompt.
+func sendSdlInput(conn *net.Conn, p Params, c distributorChannels, rec *recorder, m *mirror, done <-chan bool) {
 
+	prompt := rune(0)
+	typed := ""
 	for {
 		select {
 		case key := <-c.sdlKeyPresses:
+			if prompt != 0 {
+				if key != '\n' && (prompt == 'b' || key >= '0' && key <= '9') {
+					typed += string(key)
+					fmt.Print(string(key))
+					continue
+				}
+				fmt.Println()
+				if key == '\n' {
+					if typed != "" {
+						fmt.Fprintf(*conn, string(prompt)+" "+typed+"\n")
+					}
+					prompt = 0
+					continue
+				}
+				prompt = 0
+				fmt.Println("Cancelled")
+			}
+			if key == '\n' {
+				continue
+			}
+			if _, ok := prompts[key]; ok {
+				prompt = key
+				typed = ""
+				fmt.Print(prompts[key])
+				continue
+			}
+			// Recording and snapshots happen in the controller, so the server never sees 'r' or 's'.
+			if key == 'r' {
+				rec.toggle(c)
+				continue
+			}
+			if key == 's' {
+				turn, world := m.snapshot()
+				writePgm(p, c, turn, world)
+				continue
+			}
 			sendKey := string(key) + "\n"
 			fmt.Fprintf(*conn, sendKey)
 		case <-done:
 			return
-		default:
 		}
 	}
 }
@@ -123,7 +206,7 @@ func send(conn *net.Conn, p Params, world [][]byte) {
 	// lets create the message we want to send accross
 	var stringParams string
 
-	stringParams = strconv.Itoa(p.ImageHeight) + " " + strconv.Itoa(p.ImageWidth) + " " + strconv.Itoa(p.Threads) + " " + strconv.Itoa(p.Turns) + "\n"
+	stringParams = strconv.Itoa(p.ImageHeight) + " " + strconv.Itoa(p.ImageWidth) + " " + strconv.Itoa(p.Threads) + " " + strconv.Itoa(p.Turns) + " " + p.Rule + " " + strconv.Itoa(p.AutosaveEvery) + " " + p.SessionID + " " + strconv.FormatBool(p.CountEveryTurn) + " " + p.TickerPeriod.String() + " " + strconv.Itoa(p.TickerEvery) + " " + strconv.FormatFloat(p.TurnsPerSecond, 'g', -1, 64) + " " + strconv.Itoa(p.HistoryDepth) + " " + strconv.FormatBool(p.FinishWhenStable) + " " + strconv.Itoa(p.RewindDepth) + " " + strconv.Itoa(p.CheckpointEvery) + breakpointFields(p.Breakpoints) + "\n"
 
 	// var stringWorld string
 
@@ -149,10 +232,20 @@ func send(conn *net.Conn, p Params, world [][]byte) {
 	fmt.Fprintf(*conn, sendMessage)
 }
 
+// breakpointFields adds every breakpoint to the end of the parameters line as its own named field,
+// as in " breakpoint=alive<100 breakpoint=periodic". Breakpoints never contain spaces.
+func breakpointFields(breakpoints []string) string {
+	var fields strings.Builder
+	for _, spec := range breakpoints {
+		fields.WriteString(" breakpoint=" + spec)
+	}
+	return fields.String()
+}
+
 func createWorldAliveCells(p Params, aliveCells []util.Cell) [][]byte {
-	initialWorld := make([][]byte, p.ImageHeight)
+	initialWorld := make([][]byte, p.ImageWidth)
 	for i := range initialWorld {
-		initialWorld[i] = make([]byte, p.ImageWidth)
+		initialWorld[i] = make([]byte, p.ImageHeight)
 	}
 
 	for _, x := range aliveCells {
@@ -162,7 +255,7 @@ func createWorldAliveCells(p Params, aliveCells []util.Cell) [][]byte {
 	return initialWorld
 }
 
-func makeFinalTurnComplete(conn *net.Conn, p Params, c distributorChannels, reader *bufio.Reader, done chan<- bool) {
+func makeFinalTurnComplete(conn *net.Conn, p Params, c distributorChannels, reader *bufio.Reader, rec *recorder, done chan<- bool) {
 	//fmt.Println("Sunt si in functie")
 	turnsString, _ := reader.ReadString('\n')
 
@@ -189,7 +282,7 @@ func makeFinalTurnComplete(conn *net.Conn, p Params, c distributorChannels, read
 		Alive:          aliveCells,
 	}
 
-	closeProgramm(c, turn, done)
+	closeProgramm(c, rec, turn, done)
 	(*conn).Close()
 }
 
@@ -235,13 +328,13 @@ func makeEventWritePgm(conn *net.Conn, p Params, c distributorChannels, reader *
 
 }
 
-func makeCloseProgramEvent(conn *net.Conn, p Params, c distributorChannels, reader *bufio.Reader, done chan<- bool) {
+func makeCloseProgramEvent(conn *net.Conn, p Params, c distributorChannels, reader *bufio.Reader, rec *recorder, done chan<- bool) {
 	turnsString, _ := reader.ReadString('\n')
 
 	turnsString = turnsString[:(len(turnsString))-1]
 	turn, _ := strconv.Atoi(turnsString)
 
-	closeProgramm(c, turn, done)
+	closeProgramm(c, rec, turn, done)
 
 }
 
@@ -265,14 +358,75 @@ func makeEventExecutingProgram(conn *net.Conn, p Params, c distributorChannels,
 
 }
 
-func makeTurnCompleteEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader, lastCells []util.Cell) []util.Cell {
+// makeTurnCompleteEvent reads the cells that flipped during a turn and forwards them to the GUI.
+// The copy of the world is checked against the hash of the world that follows them, and is requested again with 'y'
+// if they differ.
+func makeTurnCompleteEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader, rec *recorder, m *mirror) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
+
+	flippedCellsString, _ := reader.ReadString('\n')
+
+	flippedCellsArray := strings.Fields(flippedCellsString)
+
+	var flippedCells []util.Cell
+
+	for i := 0; i < len(flippedCellsArray); i = i + 2 {
+		cell := util.Cell{}
+		cell.X, _ = strconv.Atoi(flippedCellsArray[i])
+		cell.Y, _ = strconv.Atoi(flippedCellsArray[i+1])
+		flippedCells = append(flippedCells, cell)
+	}
+
+	for _, cell := range flippedCells {
+		c.events <- CellFlipped{
+			turn,
+			cell,
+		}
+	}
+
+	hashString, _ := reader.ReadString('\n')
+	hash, _ := strconv.ParseUint(strings.TrimSpace(hashString), 16, 64)
+
+	if m.turnComplete(turn, flippedCells, hash) {
+		fmt.Println("World out of sync at turn", turn, "- requesting it again")
+		fmt.Fprint(*conn, "y\n")
+	}
+
+	c.events <- TurnComplete{
+		turn,
+	}
+	c.events <- WorldHash{
+		turn,
+		hash,
+	}
+
+	rec.turnComplete(c, turn, flippedCells)
+}
+
+// makeRejectedEvent ends the run when the server refuses to start a session, e.g. because it is full.
+func makeRejectedEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader, rec *recorder, done chan<- bool) {
+	reason, _ := reader.ReadString('\n')
+	c.events <- Rejected{
+		0,
+		strings.TrimSpace(reason),
+	}
+
+	closeProgramm(c, rec, 0, done)
+	(*conn).Close()
+}
+
+// makeWorldEvent reads the whole world, which a spectator is sent when it joins a session
+// and a controller when its copy of the world is out of sync. Only the cells that differ are flipped.
+func makeWorldEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader, rec *recorder, m *mirror) {
 	turnsString, _ := reader.ReadString('\n')
 
 	turnsString = turnsString[:(len(turnsString))-1]
 	turn, _ := strconv.Atoi(turnsString)
 
 	aliveCellsString, _ := reader.ReadString('\n')
-	//fmt.Println("Celule vii: ", aliveCellsString)
 
 	aliveCellsArray := strings.Fields(aliveCellsString)
 
@@ -285,14 +439,9 @@ func makeTurnCompleteEvent(conn *net.Conn, c distributorChannels, reader *bufio.
 		aliveCells = append(aliveCells, cell)
 	}
 
-	for _, cell := range lastCells {
-		c.events <- CellFlipped{
-			turn,
-			cell,
-		}
-	}
+	flippedCells := m.replace(turn, aliveCells)
 
-	for _, cell := range aliveCells {
+	for _, cell := range flippedCells {
 		c.events <- CellFlipped{
 			turn,
 			cell,
@@ -303,12 +452,113 @@ func makeTurnCompleteEvent(conn *net.Conn, c distributorChannels, reader *bufio.
 		turn,
 	}
 
-	return aliveCells
+	rec.turnComplete(c, turn, flippedCells)
+}
+
+// makeThroughputEvent reads how fast the server has been running. Times are sent in nanoseconds.
+func makeThroughputEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
 
+	event := Throughput{CompletedTurns: turn}
+
+	totalsString, _ := reader.ReadString('\n')
+	totals := strings.Fields(totalsString)
+	if len(totals) == 2 {
+		event.TurnsPerSecond, _ = strconv.ParseFloat(totals[0], 64)
+		communication, _ := strconv.ParseInt(totals[1], 10, 64)
+		event.Communication = time.Duration(communication)
+	}
+
+	computeString, _ := reader.ReadString('\n')
+	for _, field := range strings.Fields(computeString) {
+		compute, _ := strconv.ParseInt(field, 10, 64)
+		event.Compute = append(event.Compute, time.Duration(compute))
+	}
+
+	waitString, _ := reader.ReadString('\n')
+	for _, field := range strings.Fields(waitString) {
+		wait, _ := strconv.ParseInt(field, 10, 64)
+		event.BarrierWait = append(event.BarrierWait, time.Duration(wait))
+	}
+
+	c.events <- event
+}
+
+// makeSpeedChangeEvent reads the target rate of the server after it has been changed with '+' or '-'.
+func makeSpeedChangeEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
+
+	rateString, _ := reader.ReadString('\n')
+	rate, _ := strconv.ParseFloat(strings.TrimSpace(rateString), 64)
+
+	c.events <- SpeedChange{turn, rate}
+}
+
+// makeBreakpointHitEvent reads which breakpoint made the server pause and why.
+func makeBreakpointHitEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
+
+	breakpoint, _ := reader.ReadString('\n')
+	reason, _ := reader.ReadString('\n')
+
+	c.events <- BreakpointHit{turn, strings.TrimSpace(breakpoint), strings.TrimSpace(reason)}
+}
+
+// makeStabilisedEvent reads the period of the world once the server has noticed that it repeats.
+func makeStabilisedEvent(conn *net.Conn, c distributorChannels, reader *bufio.Reader) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
+
+	periodString, _ := reader.ReadString('\n')
+	period, _ := strconv.Atoi(strings.TrimSpace(periodString))
+	firstTurnString, _ := reader.ReadString('\n')
+	firstTurn, _ := strconv.Atoi(strings.TrimSpace(firstTurnString))
+
+	c.events <- Stabilised{turn, period, firstTurn}
+}
+
+// makeWorldAtEvent saves the world of a past turn the server has computed again, naming it after that turn.
+// The line before the cells holds why the world could not be computed, and is empty if it was.
+func makeWorldAtEvent(conn *net.Conn, p Params, c distributorChannels, reader *bufio.Reader) {
+	turnsString, _ := reader.ReadString('\n')
+
+	turnsString = turnsString[:(len(turnsString))-1]
+	turn, _ := strconv.Atoi(turnsString)
+
+	errorString, _ := reader.ReadString('\n')
+	aliveCellsString, _ := reader.ReadString('\n')
+	if strings.TrimSpace(errorString) != "" {
+		fmt.Println("Cannot save the world at turn", turn, "-", strings.TrimSpace(errorString))
+		return
+	}
+
+	aliveCellsArray := strings.Fields(aliveCellsString)
+
+	var aliveCells []util.Cell
+
+	for i := 0; i < len(aliveCellsArray); i = i + 2 {
+		cell := util.Cell{}
+		cell.X, _ = strconv.Atoi(aliveCellsArray[i])
+		cell.Y, _ = strconv.Atoi(aliveCellsArray[i+1])
+		aliveCells = append(aliveCells, cell)
+	}
+
+	writePgm(p, c, turn, createWorldAliveCells(p, aliveCells))
 }
 
 // REFACTOR (Use w)
-func receive(conn *net.Conn, c distributorChannels, p Params, done chan<- bool, lastCells []util.Cell) {
+func receive(conn *net.Conn, c distributorChannels, p Params, done chan<- bool, rec *recorder, m *mirror) {
 	reader := bufio.NewReader(*conn)
 	for {
 
@@ -327,15 +577,29 @@ func receive(conn *net.Conn, c distributorChannels, p Params, done chan<- bool,
 			case AliveCellsCountEvent:
 				printAliveCells(conn, p, c, reader)
 			case FinalTurnCompleteEvent:
-				makeFinalTurnComplete(conn, p, c, reader, done)
+				makeFinalTurnComplete(conn, p, c, reader, rec, done)
 			case QuittingEvent:
-				makeCloseProgramEvent(conn, p, c, reader, done)
+				makeCloseProgramEvent(conn, p, c, reader, rec, done)
 			case PauseEvent:
 				makeEventPauseProgram(conn, p, c, reader)
 			case ExecutingEvent:
 				makeEventExecutingProgram(conn, p, c, reader)
 			case TurnCompleteEvent:
-				lastCells = makeTurnCompleteEvent(conn, c, reader, lastCells)
+				makeTurnCompleteEvent(conn, c, reader, rec, m)
+			case RejectedEvent:
+				makeRejectedEvent(conn, c, reader, rec, done)
+			case WorldEvent:
+				makeWorldEvent(conn, c, reader, rec, m)
+			case ThroughputEvent:
+				makeThroughputEvent(conn, c, reader)
+			case SpeedEvent:
+				makeSpeedChangeEvent(conn, c, reader)
+			case BreakpointHitEvent:
+				makeBreakpointHitEvent(conn, c, reader)
+			case StabilisedEvent:
+				makeStabilisedEvent(conn, c, reader)
+			case WorldAtEvent:
+				makeWorldAtEvent(conn, p, c, reader)
 			}
 
 			// if code == 4 {
@@ -355,27 +619,76 @@ func receive(conn *net.Conn, c distributorChannels, p Params, done chan<- bool,
 	}
 }
 
+// describeSession asks the server for the parameters of the session a spectator is going to watch.
+func describeSession(p Params) Params {
+	server := p.Server
+	if server == "" {
+		server = DefaultServer
+	}
+	conn, err := net.Dial("tcp", server)
+	util.Check(err)
+	defer conn.Close()
+
+	fmt.Fprint(conn, "describe "+p.Spectate+"\n")
+	describeString, _ := bufio.NewReader(conn).ReadString('\n')
+
+	fields := strings.Fields(describeString)
+	if len(fields) < 7 {
+		panic("No session " + p.Spectate + " is running on " + server)
+	}
+	p.ImageHeight, _ = strconv.Atoi(fields[0])
+	p.ImageWidth, _ = strconv.Atoi(fields[1])
+	p.Threads, _ = strconv.Atoi(fields[2])
+	p.Turns, _ = strconv.Atoi(fields[3])
+	p.Rule = fields[4]
+	p.AutosaveEvery, _ = strconv.Atoi(fields[5])
+	p.SessionID = fields[6]
+	return p
+}
+
+// spectate watches a session started by another controller. The world is sent by the server once it has joined.
+// Snapshots and recordings are still made locally.
+func spectate(p Params, c distributorChannels) {
+	conn, err := net.Dial("tcp", p.Server)
+	util.Check(err)
+
+	done := make(chan bool)
+
+	rec := newRecorder(p, nil)
+	m := newMirror(p, nil)
+
+	fmt.Fprint(conn, "spectate "+p.Spectate+"\n")
+	go receive(&conn, c, p, done, rec, m)
+	go sendSdlInput(&conn, p, c, rec, m, done)
+}
+
 func controller(p Params, c distributorChannels) {
+	if p.Spectate != "" {
+		spectate(p, c)
+		return
+	}
 
 	fmt.Println("Intasi in controller")
 	// READ
 	c.ioCommand <- 1
-	c.ioFilename <- fmt.Sprintf("%vx%v", p.ImageWidth, p.ImageHeight)
+	c.ioFilename <- inputPath(p)
 
 	// TODO: Create a 2D slice to store the world.
 	// TODO: For all initially alive cells send a CellFlipped Event.
 	world := getInitialWorld(p, c)
 	//fmt.Println(p, world)
 
-	conn, _ := net.Dial("tcp", "127.0.0.1:8030")
+	conn, _ := net.Dial("tcp", p.Server)
 
 	done := make(chan bool)
 
-	initialCells := getCurrentAliveCells(world)
+	aliveCells := getCurrentAliveCells(world)
+	rec := newRecorder(p, aliveCells)
+	m := newMirror(p, aliveCells)
 
 	send(&conn, p, world)
-	go receive(&conn, c, p, done, initialCells)
-	go sendSdlInput(&conn, c, done)
+	go receive(&conn, c, p, done, rec, m)
+	go sendSdlInput(&conn, p, c, rec, m, done)
 
 	//send world to server
 
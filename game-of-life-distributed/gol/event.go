@@ -2,6 +2,7 @@ package gol
 
 import (
 	"fmt"
+	"time"
 
 	"uk.ac.bris.cs/gameoflife/util"
 )
@@ -60,6 +61,57 @@ type TurnComplete struct { // implements Event
 	CompletedTurns int
 }
 
+// WorldHash is an Event giving the 64-bit hash of the world after a turn, as computed by the server.
+// Two runs of the same world and rule have the same hashes, whatever the number of threads.
+// It is sent straight after the TurnComplete of the same turn.
+type WorldHash struct { // implements Event
+	CompletedTurns int
+	Hash           uint64
+}
+
+// Throughput is an Event reporting how fast the server has been running since the previous Throughput.
+// It is sent together with AliveCellsCount. Times are averages per turn.
+type Throughput struct { // implements Event
+	CompletedTurns int
+	TurnsPerSecond float64
+	// Compute is how long each worker took to compute its strip.
+	Compute []time.Duration
+	// BarrierWait is how long each worker waited for the slowest one to finish.
+	BarrierWait []time.Duration
+	// Communication is how long sending the turn to the controller and spectators took.
+	Communication time.Duration
+}
+
+// SpeedChange is an Event notifying the user that the target rate of the server has been changed.
+// A TurnsPerSecond of 0 means the server runs as fast as it can.
+type SpeedChange struct { // implements Event
+	CompletedTurns int
+	TurnsPerSecond float64
+}
+
+// BreakpointHit is an Event notifying the user that the condition of a breakpoint has become true.
+// The server pauses before the next turn, sending StateChange, unless it was already paused.
+type BreakpointHit struct { // implements Event
+	CompletedTurns int
+	Breakpoint     string
+	Reason         string
+}
+
+// Stabilised is an Event notifying the user that the world repeats. The world after FirstTurn is the same as
+// the world Period turns later, and so on for every turn after it. It is sent once, when the repeat is first seen.
+type Stabilised struct { // implements Event
+	CompletedTurns int
+	Period         int
+	FirstTurn      int
+}
+
+// Rejected is an Event notifying the user that the server refused to start the session, e.g. because it is full.
+// It is followed by StateChange to Quitting, without a FinalTurnComplete.
+type Rejected struct { // implements Event
+	CompletedTurns int
+	Reason         string
+}
+
 // FinalTurnComplete is an Event notifying the testing framework about the new world state after execution finished.
 // The data included with this Event is used directly by the tests.
 // SDL ignores this Event.
@@ -123,6 +175,82 @@ func (event TurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event WorldHash) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event WorldHash) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event Throughput) String() string {
+	return fmt.Sprintf("Throughput %.1f turns/s, compute %v, barrier wait %v, communication %v",
+		event.TurnsPerSecond, durationRange(event.Compute), durationRange(event.BarrierWait), event.Communication)
+}
+
+func (event Throughput) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+// durationRange gives the shortest and longest of the times of the workers, as in "1.2ms-3.4ms",
+// so that a worker with more to do than the others shows up. The longest is what holds up a turn.
+func durationRange(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "0s"
+	}
+	shortest, longest := durations[0], durations[0]
+	for _, duration := range durations {
+		if duration < shortest {
+			shortest = duration
+		}
+		if duration > longest {
+			longest = duration
+		}
+	}
+	if shortest == longest {
+		return longest.String()
+	}
+	return shortest.String() + "-" + longest.String()
+}
+
+func (event SpeedChange) String() string {
+	if event.TurnsPerSecond == 0 {
+		return fmt.Sprintf("Speed unlimited")
+	}
+	return fmt.Sprintf("Speed %v turns/s", event.TurnsPerSecond)
+}
+
+func (event SpeedChange) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event BreakpointHit) String() string {
+	return fmt.Sprintf("Breakpoint %v hit: %v", event.Breakpoint, event.Reason)
+}
+
+func (event BreakpointHit) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event Stabilised) String() string {
+	if event.Period == 1 {
+		return fmt.Sprintf("Still life from turn %v", event.FirstTurn)
+	}
+	return fmt.Sprintf("Stabilised with period %v from turn %v", event.Period, event.FirstTurn)
+}
+
+func (event Stabilised) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event Rejected) String() string {
+	return fmt.Sprintf("Server rejected the session: %v", event.Reason)
+}
+
+func (event Rejected) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event FinalTurnComplete) String() string {
 	return fmt.Sprintf("")
 }
@@ -1,22 +1,139 @@
 package gol
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// DefaultRule is the rule of Conway's Game of Life in B/S notation.
+const DefaultRule = "B3/S23"
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
 	Turns       int
 	Threads     int
 	ImageWidth  int
 	ImageHeight int
+	InputFile   string
+	OutputDir   string
+	// Rule is given in B/S notation, e.g. B3/S23.
+	Rule string
+	// PatternOffset is where the top left corner of a pattern is placed. Nil centres the pattern.
+	PatternOffset *util.Cell
+	// PngScale is the size in pixels of a cell in png output. 0 disables png output.
+	PngScale int
+	// PngThreshold is the brightness above which a pixel of a png input is alive.
+	PngThreshold uint8
+	// GifEvery is how many turns apart the frames of a gif recording are.
+	GifEvery int
+	// GifDelay is how long each frame of a gif recording is shown for.
+	GifDelay time.Duration
+	// GifMaxFrames is the number of frames after which a gif recording is stopped and saved.
+	GifMaxFrames int
+	// FilenameTemplate names snapshots. {width}, {height}, {turn}, {time} and {session} are replaced.
+	FilenameTemplate string
+	// AutosaveEvery is how many turns apart the server sends the world to be saved. 0 disables autosaving.
+	AutosaveEvery int
+	// SessionID identifies this run in snapshot names and the snapshot index. A random one is chosen if empty.
+	SessionID string
+	// Server is the address of the server running the simulation.
+	Server string
+	// ViewerAddr is the address the browser viewer listens on, e.g. :8080. Empty disables the viewer.
+	ViewerAddr string
+	// CountEveryTurn sends AliveCellsCount after every turn as well as every 2 seconds.
+	CountEveryTurn bool
+	// TickerPeriod is how often the server sends AliveCellsCount and Throughput. 0 uses the default of 2 seconds.
+	// The t key cycles through other periods while running.
+	TickerPeriod time.Duration
+	// TickerEvery sends AliveCellsCount and Throughput after every that many turns instead of every TickerPeriod,
+	// so that the turns they report are the same every run. 0 disables it.
+	TickerEvery int
+	// TurnsPerSecond is the most turns the server runs a second, so that patterns can be followed. 0 runs as fast as possible.
+	// The + and - keys change it while running, and n advances a single turn while paused.
+	TurnsPerSecond float64
+	// Breakpoints pause the server when their condition becomes true, sending BreakpointHit. Each is one of
+	// alive<N, alive>N, empty:X,Y,W,H, nonempty:X,Y,W,H, periodic or cell:X,Y.
+	// More are added while running by typing b, the breakpoint and enter, and "b clear" removes them all.
+	Breakpoints []string
+	// HistoryDepth is how many turns back the server looks for the same world to notice that it repeats,
+	// which is the longest period it finds. 0 uses the default of 100.
+	HistoryDepth int
+	// FinishWhenStable ends the run as soon as the world repeats, sending the FinalTurnComplete of the last turn
+	// worked out from the period instead of computing every turn.
+	FinishWhenStable bool
+	// RewindDepth is how many turns the server keeps to step back through while paused, with u for a single turn
+	// and j for a number of turns. Resuming continues from the earlier turn. 0 uses the default of 100.
+	RewindDepth int
+	// CheckpointEvery is how many turns apart the server starts out keeping worlds, from which the world of any
+	// past turn is computed again when w asks for it to be saved. 0 uses the default of 100.
+	CheckpointEvery int
+	// Spectate is the ID of a running session to watch instead of starting a new one. Only local snapshots and recordings can be made.
+	Spectate string
+}
+
+// DefaultServer is where the server listens when run locally.
+const DefaultServer = "127.0.0.1:8030"
+
+// DefaultFilenameTemplate names snapshots as the tests expect, e.g. 512x512x100.
+const DefaultFilenameTemplate = "{width}x{height}x{turn}"
+
+// newSessionID returns a random hexadecimal session identifier.
+func newSessionID() string {
+	id := make([]byte, 4)
+	_, err := rand.Read(id)
+	util.Check(err)
+	return hex.EncodeToString(id)
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 
+	p = ReadInputHeader(p)
+	if p.OutputDir == "" {
+		p.OutputDir = "out"
+	}
+	if p.Server == "" {
+		p.Server = DefaultServer
+	}
+	if p.FilenameTemplate == "" {
+		p.FilenameTemplate = DefaultFilenameTemplate
+	}
+	if p.SessionID == "" {
+		p.SessionID = newSessionID()
+	}
+	if p.GifEvery < 1 {
+		p.GifEvery = 1
+	}
+	if p.GifDelay == 0 {
+		p.GifDelay = 100 * time.Millisecond
+	}
+	if p.GifMaxFrames < 1 {
+		p.GifMaxFrames = 500
+	}
+
 	ioCommand := make(chan ioCommand)
 	ioIdle := make(chan bool)
 	ioFilename := make(chan string)
 	ioOutput := make(chan uint8)
 	ioInput := make(chan uint8)
 
+	// The browser viewer sits between the controller and the GUI, seeing every event and adding its own keys.
+	if p.ViewerAddr != "" {
+		browserKeys := make(chan rune, 10)
+		keys := make(chan rune, 10)
+		controllerEvents := make(chan Event, 1000)
+
+		v := startViewer(p, browserKeys)
+		go v.forwardEvents(controllerEvents, events)
+		go mergeKeys(keyPresses, browserKeys, keys, v.done)
+
+		events = controllerEvents
+		keyPresses = keys
+	}
+
 	distributorChannels := distributorChannels{
 		events,
 		ioCommand,
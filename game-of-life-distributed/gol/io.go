@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"uk.ac.bris.cs/gameoflife/util"
 )
@@ -41,12 +43,11 @@ const (
 
 // writePgmImage receives an array of bytes and writes it to a pgm file.
 func (io *ioState) writePgmImage() {
-	_ = os.Mkdir("out", os.ModePerm)
+	_ = os.MkdirAll(io.params.OutputDir, os.ModePerm)
 
 	filename := <-io.channels.filename
-	file, ioError := os.Create("out/" + filename + ".pgm")
+	file, ioError := os.Create(filepath.Join(io.params.OutputDir, filename+".pgm"))
 	util.Check(ioError)
-	defer file.Close()
 
 	_, _ = file.WriteString("P5\n")
 	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
@@ -81,14 +82,213 @@ func (io *ioState) writePgmImage() {
 
 	ioError = file.Sync()
 	util.Check(ioError)
+	ioError = file.Close()
+	util.Check(ioError)
+
+	// Every image is also saved as RLE so that it can be shared with other Life programs.
+	var cells []util.Cell
+	for y := 0; y < io.params.ImageHeight; y++ {
+		for x := 0; x < io.params.ImageWidth; x++ {
+			if world[y][x] != 0 {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	WritePattern(filepath.Join(io.params.OutputDir, filename+".rle"), cells, io.params)
+	if io.params.PngScale > 0 {
+		util.WritePng(filepath.Join(io.params.OutputDir, filename+".png"), cells, io.params.ImageWidth, io.params.ImageHeight, io.params.PngScale)
+	}
 
 	fmt.Println("File", filename, "output done!")
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
-func (io *ioState) readPgmImage() {
+// writeIndexEntry records a snapshot in the index file of the output directory.
+// Every line of the index holds the session, turn, time, filename and world hash of one snapshot.
+func writeIndexEntry(p Params, turn int, filename string, hash uint64) {
+	_ = os.MkdirAll(p.OutputDir, os.ModePerm)
+
+	file, ioError := os.OpenFile(filepath.Join(p.OutputDir, "index.txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	util.Check(ioError)
+	defer file.Close()
+
+	_, ioError = fmt.Fprintf(file, "%v %v %v %v %016x\n", p.SessionID, turn, time.Now().Format(time.RFC3339), filename, hash)
+	util.Check(ioError)
+}
+
+// ReadInputHeader fills in the parameters given by the header of p.InputFile.
+// A pgm file sets the dimensions of the world and an RLE file sets the rule, unless one was chosen already.
+// Pattern files only set the dimensions if none were given.
+func ReadInputHeader(p Params) Params {
+	if p.Spectate != "" {
+		return describeSession(p)
+	}
+	if p.InputFile != "" {
+		if isPattern(p.InputFile) {
+			_, width, height, rule := readPattern(p.InputFile)
+			if p.Rule == "" {
+				p.Rule = rule
+			}
+			if p.ImageWidth == 0 || p.ImageHeight == 0 {
+				if width == 0 || height == 0 {
+					panic("The size of the world must be given for " + p.InputFile)
+				}
+				p.ImageWidth, p.ImageHeight = width, height
+			}
+		} else if filepath.Ext(p.InputFile) == ".png" {
+			_, p.ImageWidth, p.ImageHeight = util.ReadPng(p.InputFile, p.PngThreshold)
+		} else {
+			p.ImageWidth, p.ImageHeight = readImageSize(p.InputFile)
+		}
+	}
+	if p.Rule == "" {
+		p.Rule = DefaultRule
+	}
+	// The rule may come from the header of an RLE file rather than from -rule.
+	_, _, err := util.ParseRule(p.Rule)
+	util.Check(err)
+	return p
+}
+
+// isPattern reports whether a file holds a pattern rather than a pgm image.
+func isPattern(path string) bool {
+	switch filepath.Ext(path) {
+	case ".rle", ".cells", ".lif", ".life":
+		return true
+	}
+	return false
+}
+
+// readPattern reads the alive cells of a pattern file in the format given by its extension.
+// It also returns the size and rule of the pattern where the format stores them, and zero values otherwise.
+func readPattern(path string) ([]util.Cell, int, int, string) {
+	switch filepath.Ext(path) {
+	case ".rle":
+		return util.ReadRle(path)
+	case ".cells":
+		cells, width, height := util.ReadPlaintext(path)
+		return cells, width, height, ""
+	case ".lif", ".life":
+		return util.ReadLife106(path), 0, 0, ""
+	default:
+		panic("Unknown pattern format " + path)
+	}
+}
+
+// WritePattern saves the alive cells of a world to path in the format given by its extension.
+// The supported formats are RLE (.rle), plaintext (.cells) and Life 1.06 (.lif or .life).
+func WritePattern(path string, cells []util.Cell, p Params) {
+	switch filepath.Ext(path) {
+	case ".rle":
+		util.WriteRle(path, cells, p.ImageWidth, p.ImageHeight, p.Rule)
+	case ".cells":
+		util.WritePlaintext(path, cells, p.ImageWidth, p.ImageHeight)
+	case ".lif", ".life":
+		util.WriteLife106(path, cells)
+	default:
+		panic("Unknown pattern format " + path)
+	}
+}
+
+// readImageSize returns the width and height stored in the header of a pgm file.
+func readImageSize(path string) (int, int) {
+	data, ioError := ioutil.ReadFile(path)
+	util.Check(ioError)
+
+	fields := strings.Fields(string(data))
+
+	if len(fields) < 3 || fields[0] != "P5" {
+		panic("Not a pgm file")
+	}
+
+	width, _ := strconv.Atoi(fields[1])
+	height, _ := strconv.Atoi(fields[2])
+
+	return width, height
+}
+
+// readImage opens the file named on the filename channel and sends the world it holds as an array of bytes.
+// The format of the file is chosen by its extension.
+func (io *ioState) readImage() {
 	filename := <-io.channels.filename
-	data, ioError := ioutil.ReadFile("images/" + filename + ".pgm")
+	if isPattern(filename) {
+		io.readPatternImage(filename)
+	} else if filepath.Ext(filename) == ".png" {
+		io.readPngImage(filename)
+	} else {
+		io.readPgmImage(filename)
+	}
+}
+
+// readPatternImage places the pattern stored in a file in the world and sends the world as an array of bytes.
+// The pattern is centred unless params.PatternOffset gives the position of its top left corner.
+// Life 1.06 coordinates have no bounding box, so they are used as world coordinates and only moved by the offset.
+func (io *ioState) readPatternImage(filename string) {
+	cells, width, height, _ := readPattern(filename)
+
+	var offset util.Cell
+	if io.params.PatternOffset != nil {
+		offset = *io.params.PatternOffset
+	} else if width > 0 && height > 0 {
+		offset = util.Cell{
+			X: (io.params.ImageWidth - width) / 2,
+			Y: (io.params.ImageHeight - height) / 2,
+		}
+	}
+
+	var placed []util.Cell
+	for _, cell := range cells {
+		placed = append(placed, util.Cell{
+			X: mod(cell.X+offset.X, io.params.ImageWidth),
+			Y: mod(cell.Y+offset.Y, io.params.ImageHeight),
+		})
+	}
+
+	io.sendCells(placed)
+
+	fmt.Println("File", filename, "input done!")
+}
+
+// readPngImage opens a png image and sends the world it shows as an array of bytes.
+// Pixels brighter than params.PngThreshold are alive.
+func (io *ioState) readPngImage(filename string) {
+	cells, width, height := util.ReadPng(filename, io.params.PngThreshold)
+	if width != io.params.ImageWidth {
+		panic("Incorrect width")
+	}
+	if height != io.params.ImageHeight {
+		panic("Incorrect height")
+	}
+
+	io.sendCells(cells)
+
+	fmt.Println("File", filename, "input done!")
+}
+
+// sendCells sends a world where only the given cells are alive as an array of bytes.
+func (io *ioState) sendCells(cells []util.Cell) {
+	world := make([][]byte, io.params.ImageHeight)
+	for i := range world {
+		world[i] = make([]byte, io.params.ImageWidth)
+	}
+	for _, cell := range cells {
+		world[cell.Y][cell.X] = 255
+	}
+
+	for y := 0; y < io.params.ImageHeight; y++ {
+		for x := 0; x < io.params.ImageWidth; x++ {
+			io.channels.input <- world[y][x]
+		}
+	}
+}
+
+// mod wraps x around a dimension of size m, including when x is negative.
+func mod(x, m int) int {
+	return ((x % m) + m) % m
+}
+
+// readPgmImage opens a pgm file and sends its data as an array of bytes.
+func (io *ioState) readPgmImage(filename string) {
+	data, ioError := ioutil.ReadFile(filename)
 	util.Check(ioError)
 
 	fields := strings.Fields(string(data))
@@ -133,7 +333,7 @@ func startIo(p Params, c ioChannels) {
 		case command := <-io.channels.command:
 			switch command {
 			case ioInput:
-				io.readPgmImage()
+				io.readImage()
 			case ioOutput:
 				io.writePgmImage()
 			case ioCheckIdle:
@@ -0,0 +1,82 @@
+package gol
+
+import (
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// mirror is the controller's own copy of the world, kept up to date from the cells flipped every turn.
+// Snapshots are written from it without asking the server, and it is checked against the hash the server sends every turn.
+type mirror struct {
+	mutex sync.Mutex
+	p     Params
+	world [][]byte
+	turn  int
+	hash  uint64
+	// requested is set while the whole world has been requested from the server but has not arrived yet.
+	requested bool
+}
+
+func newMirror(p Params, aliveCells []util.Cell) *mirror {
+	m := &mirror{p: p, world: createWorldAliveCells(p, nil)}
+	m.replace(0, aliveCells)
+	return m
+}
+
+// turnComplete applies the cells flipped during a turn and checks the world against the hash the server sent.
+// It returns true when the world has just gone out of sync and should be requested again.
+func (m *mirror) turnComplete(turn int, flipped []util.Cell, hash uint64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.turn = turn
+	m.flip(flipped)
+	if m.hash != hash && !m.requested {
+		m.requested = true
+		return true
+	}
+	return false
+}
+
+// replace sets the whole world and returns the cells that had to be flipped to get there.
+func (m *mirror) replace(turn int, aliveCells []util.Cell) []util.Cell {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	world := createWorldAliveCells(m.p, aliveCells)
+	var flipped []util.Cell
+	for x := range world {
+		for y := range world[x] {
+			if world[x][y] != m.world[x][y] {
+				flipped = append(flipped, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+
+	m.world = world
+	m.turn = turn
+	m.hash = util.WorldHash(world)
+	m.requested = false
+	return flipped
+}
+
+// flip changes the state of the cells, updating the hash as it goes. The mutex must be held.
+func (m *mirror) flip(cells []util.Cell) {
+	for _, cell := range cells {
+		m.world[cell.X][cell.Y] = 255 - m.world[cell.X][cell.Y]
+		m.hash ^= util.CellHash(cell.X, cell.Y)
+	}
+}
+
+// snapshot returns the latest completed turn and a copy of its world.
+func (m *mirror) snapshot() (int, [][]byte) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	world := make([][]byte, len(m.world))
+	for x := range world {
+		world[x] = append([]byte(nil), m.world[x]...)
+	}
+	return m.turn, world
+}
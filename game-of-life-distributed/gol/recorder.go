@@ -0,0 +1,111 @@
+package gol
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// recorder captures every p.GifEvery turns of the world into an animated gif while recording is on.
+// It follows every flipped cell so that a recording can start at any turn.
+type recorder struct {
+	mutex     sync.Mutex
+	p         Params
+	world     *image.Paletted
+	turn      int
+	recording bool
+	startTurn int
+	frames    []*image.Paletted
+}
+
+var recorderPalette = color.Palette{color.Black, color.White}
+
+func newRecorder(p Params, aliveCells []util.Cell) *recorder {
+	world := image.NewPaletted(image.Rect(0, 0, p.ImageWidth, p.ImageHeight), recorderPalette)
+	for _, cell := range aliveCells {
+		world.SetColorIndex(cell.X, cell.Y, 1)
+	}
+	return &recorder{
+		p:     p,
+		world: world,
+	}
+}
+
+// turnComplete applies the cells flipped during a turn and captures a frame if one is due.
+func (r *recorder) turnComplete(c distributorChannels, turn int, flipped []util.Cell) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.turn = turn
+	for _, cell := range flipped {
+		r.world.SetColorIndex(cell.X, cell.Y, 1-r.world.ColorIndexAt(cell.X, cell.Y))
+	}
+
+	if r.recording && (turn-r.startTurn)%r.p.GifEvery == 0 {
+		r.capture()
+		if len(r.frames) >= r.p.GifMaxFrames {
+			r.save(c, turn)
+		}
+	}
+}
+
+// toggle starts a recording at the latest completed turn, or stops and saves the recording in progress.
+func (r *recorder) toggle(c distributorChannels) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.recording {
+		r.save(c, r.turn)
+		return
+	}
+
+	fmt.Println("Recording started")
+	r.recording = true
+	r.startTurn = r.turn
+	r.capture()
+}
+
+// stop saves the recording in progress, if there is one.
+func (r *recorder) stop(c distributorChannels, turn int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.recording {
+		r.save(c, turn)
+	}
+}
+
+func (r *recorder) capture() {
+	frame := image.NewPaletted(r.world.Rect, recorderPalette)
+	copy(frame.Pix, r.world.Pix)
+	r.frames = append(r.frames, frame)
+}
+
+// save writes the captured frames to a gif in the output directory. The mutex must be held.
+func (r *recorder) save(c distributorChannels, turn int) {
+	r.recording = false
+
+	animation := gif.GIF{}
+	for _, frame := range r.frames {
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, int(r.p.GifDelay.Seconds()*100))
+	}
+	r.frames = nil
+
+	_ = os.MkdirAll(r.p.OutputDir, os.ModePerm)
+	filename := fmt.Sprintf("%vx%vx%v-%v.gif", r.p.ImageWidth, r.p.ImageHeight, r.startTurn, turn)
+	file, ioError := os.Create(filepath.Join(r.p.OutputDir, filename))
+	util.Check(ioError)
+	defer file.Close()
+
+	ioError = gif.EncodeAll(file, &animation)
+	util.Check(ioError)
+
+	c.events <- ImageOutputComplete{turn, filename}
+}
@@ -0,0 +1,345 @@
+package gol
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// viewerMessage is the json sent to browsers. Cells are sent as [x, y] pairs.
+type viewerMessage struct {
+	Type   string   `json:"type"`
+	Turn   int      `json:"turn"`
+	Width  int      `json:"width,omitempty"`
+	Height int      `json:"height,omitempty"`
+	Cells  [][2]int `json:"cells,omitempty"`
+	Count  int      `json:"count,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// viewerClient is a connected browser. Messages are queued so that a slow browser never holds up the controller.
+type viewerClient struct {
+	ws       *websocket
+	messages chan []byte
+}
+
+// viewer serves the browser page and streams events to every connected browser.
+// It keeps its own copy of the world so that browsers connecting part way through a run can be sent it.
+type viewer struct {
+	mutex   sync.Mutex
+	p       Params
+	world   [][]bool
+	turn    int
+	pending [][2]int
+	clients map[*viewerClient]bool
+	keys    chan<- rune
+	server  *http.Server
+	done    chan struct{}
+}
+
+// startViewer starts the http server of the viewer on p.ViewerAddr.
+// Keys clicked in the browser are sent on keys.
+func startViewer(p Params, keys chan<- rune) *viewer {
+	world := make([][]bool, p.ImageWidth)
+	for i := range world {
+		world[i] = make([]bool, p.ImageHeight)
+	}
+
+	v := &viewer{
+		p:       p,
+		world:   world,
+		clients: make(map[*viewerClient]bool),
+		keys:    keys,
+		done:    make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", v.servePage)
+	mux.HandleFunc("/ws", v.serveWebsocket)
+	v.server = &http.Server{Addr: p.ViewerAddr, Handler: mux}
+
+	go func() {
+		err := v.server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Println("Viewer error:", err)
+		}
+	}()
+	fmt.Println("Viewer listening on", p.ViewerAddr)
+
+	return v
+}
+
+// forwardEvents passes every event on to the GUI and to the browsers, closing events once in is closed.
+func (v *viewer) forwardEvents(in <-chan Event, events chan<- Event) {
+	for event := range in {
+		events <- event
+		v.handleEvent(event)
+	}
+	close(events)
+	v.stop()
+}
+
+func (v *viewer) handleEvent(event Event) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	switch e := event.(type) {
+	case CellFlipped:
+		v.pending = append(v.pending, [2]int{e.Cell.X, e.Cell.Y})
+	case TurnComplete:
+		// The flipped cells only become part of the world when their turn is complete,
+		// so that a browser connecting in between never receives them twice.
+		for _, cell := range v.pending {
+			v.world[cell[0]][cell[1]] = !v.world[cell[0]][cell[1]]
+		}
+		v.turn = e.CompletedTurns
+		v.broadcast(viewerMessage{Type: "turn", Turn: e.CompletedTurns, Cells: v.pending})
+		v.pending = nil
+	case AliveCellsCount:
+		v.broadcast(viewerMessage{Type: "alive", Turn: e.CompletedTurns, Count: e.CellsCount})
+	default:
+		if len(event.String()) > 0 {
+			v.broadcast(viewerMessage{Type: "event", Turn: event.GetCompletedTurns(), Text: event.String()})
+		}
+	}
+}
+
+// broadcast queues a message for every browser. The mutex must be held.
+func (v *viewer) broadcast(message viewerMessage) {
+	data, err := json.Marshal(message)
+	util.Check(err)
+	for client := range v.clients {
+		select {
+		case client.messages <- data:
+		default:
+			// The browser has fallen too far behind to catch up.
+			v.removeClient(client)
+		}
+	}
+}
+
+// removeClient disconnects a browser. The mutex must be held.
+func (v *viewer) removeClient(client *viewerClient) {
+	if v.clients[client] {
+		delete(v.clients, client)
+		close(client.messages)
+	}
+}
+
+func (v *viewer) stop() {
+	v.mutex.Lock()
+	for client := range v.clients {
+		v.removeClient(client)
+	}
+	v.mutex.Unlock()
+	close(v.done)
+	_ = v.server.Close()
+}
+
+func (v *viewer) servePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, viewerPage)
+}
+
+func (v *viewer) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgradeWebsocket(w, r)
+	if err != nil {
+		return
+	}
+
+	client := &viewerClient{ws: ws, messages: make(chan []byte, 256)}
+
+	// The current world is queued before the client is added, so it is always its first message.
+	v.mutex.Lock()
+	var cells [][2]int
+	for x := range v.world {
+		for y := range v.world[x] {
+			if v.world[x][y] {
+				cells = append(cells, [2]int{x, y})
+			}
+		}
+	}
+	data, err := json.Marshal(viewerMessage{Type: "world", Turn: v.turn, Width: v.p.ImageWidth, Height: v.p.ImageHeight, Cells: cells})
+	util.Check(err)
+	client.messages <- data
+	v.clients[client] = true
+	v.mutex.Unlock()
+
+	go v.readKeys(client)
+
+	for message := range client.messages {
+		if ws.WriteText(message) != nil {
+			break
+		}
+	}
+	ws.Close()
+}
+
+// readKeys forwards the keys clicked in a browser until it disconnects or the viewer stops.
+func (v *viewer) readKeys(client *viewerClient) {
+	defer client.ws.Close()
+	for {
+		message, err := client.ws.ReadText()
+		if err != nil {
+			v.mutex.Lock()
+			v.removeClient(client)
+			v.mutex.Unlock()
+			return
+		}
+		if utf8.RuneCountInString(message) == 1 {
+			key, _ := utf8.DecodeRuneInString(message)
+			select {
+			case v.keys <- key:
+			case <-v.done:
+				return
+			}
+		}
+	}
+}
+
+// mergeKeys sends the keys of both the GUI and the browsers on a single channel until done is closed.
+func mergeKeys(keyPresses <-chan rune, browserKeys <-chan rune, keys chan<- rune, done <-chan struct{}) {
+	for {
+		var key rune
+		select {
+		case key = <-keyPresses:
+		case key = <-browserKeys:
+		case <-done:
+			return
+		}
+		select {
+		case keys <- key:
+		case <-done:
+			return
+		}
+	}
+}
+
+// viewerPage draws the world on a canvas and sends the keys of its buttons and the keyboard back.
+const viewerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Game of Life</title>
+<style>
+body { background: #222; color: #eee; font-family: monospace; }
+canvas { background: #000; image-rendering: pixelated; border: 1px solid #555; }
+button { font-family: monospace; margin-right: 4px; }
+</style>
+</head>
+<body>
+<div>
+<button data-key="p">Pause (p)</button>
+<button data-key="s">Save (s)</button>
+<button data-key="q">Quit (q)</button>
+<button data-key="k">Kill (k)</button>
+<button data-key="t">Ticker (t)</button>
+<button data-key="-">Slower (-)</button>
+<button data-key="+">Faster (+)</button>
+<button data-key="n">Step (n)</button>
+<button id="advance">Advance (a)</button>
+<button id="breakpoint">Breakpoint (b)</button>
+<button data-key="u">Back (u)</button>
+<button id="jump">Jump back (j)</button>
+<button id="worldAt">Save turn (w)</button>
+<span id="status">Connecting...</span>
+</div>
+<canvas id="world"></canvas>
+<pre id="log"></pre>
+<script>
+const canvas = document.getElementById("world");
+const context = canvas.getContext("2d");
+const status = document.getElementById("status");
+const log = document.getElementById("log");
+const socket = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/ws");
+let width = 0, height = 0, scale = 1, world = null;
+
+function draw(x, y) {
+  context.fillStyle = world[y * width + x] ? "#fff" : "#000";
+  context.fillRect(x * scale, y * scale, scale, scale);
+}
+
+function flip(cells) {
+  for (const [x, y] of cells || []) {
+    world[y * width + x] ^= 1;
+    draw(x, y);
+  }
+}
+
+function addLog(turn, text) {
+  log.textContent = "Completed Turns " + turn + "\t" + text + "\n" + log.textContent.split("\n").slice(0, 20).join("\n");
+}
+
+socket.onmessage = (message) => {
+  const m = JSON.parse(message.data);
+  switch (m.type) {
+  case "world":
+    width = m.width;
+    height = m.height;
+    scale = Math.max(1, Math.floor(768 / Math.max(width, height)));
+    canvas.width = width * scale;
+    canvas.height = height * scale;
+    world = new Uint8Array(width * height);
+    context.fillStyle = "#000";
+    context.fillRect(0, 0, canvas.width, canvas.height);
+    flip(m.cells);
+    status.textContent = "Turn " + m.turn;
+    break;
+  case "turn":
+    flip(m.cells);
+    status.textContent = "Turn " + m.turn;
+    break;
+  case "alive":
+    addLog(m.turn, "Alive Cells " + (m.count || 0));
+    break;
+  case "event":
+    addLog(m.turn, m.text);
+    break;
+  }
+};
+
+socket.onclose = () => { status.textContent = "Disconnected"; };
+
+for (const button of document.querySelectorAll("button[data-key]")) {
+  button.onclick = () => socket.send(button.dataset.key);
+}
+
+// Turns are typed as their key, the digits of the number of turns and enter, as on the keyboard.
+function promptTurns(key, question) {
+  const turns = prompt(question);
+  if (turns && /^[0-9]+$/.test(turns)) {
+    for (const k of key + turns + "\n") {
+      socket.send(k);
+    }
+  }
+}
+
+document.getElementById("advance").onclick = () => promptTurns("a", "Advance by how many turns?");
+document.getElementById("jump").onclick = () => promptTurns("j", "Jump back how many turns?");
+document.getElementById("worldAt").onclick = () => promptTurns("w", "Save the world at which turn?");
+
+document.getElementById("breakpoint").onclick = () => {
+  const breakpoint = prompt("Add a breakpoint: alive<N, alive>N, empty:X,Y,W,H, nonempty:X,Y,W,H, periodic, cell:X,Y or clear");
+  if (breakpoint && !/\s/.test(breakpoint)) {
+    for (const key of "b" + breakpoint + "\n") {
+      socket.send(key);
+    }
+  }
+};
+
+document.onkeydown = (e) => {
+  if ("psqkrt+-naujw0123456789".includes(e.key)) {
+    socket.send(e.key);
+  } else if (e.key === "Enter") {
+    socket.send("\n");
+  }
+};
+</script>
+</body>
+</html>
+`
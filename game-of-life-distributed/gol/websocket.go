@@ -0,0 +1,199 @@
+package gol
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is appended to the key of the client during the handshake, as required by RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Websocket opcodes used by the viewer.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// websocket is the server side of a websocket connection. Only unfragmented messages are supported,
+// which is all the viewer page ever sends.
+type websocket struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	writeMutex sync.Mutex
+}
+
+// upgradeWebsocket performs the opening handshake and takes over the connection of an http request.
+// Upgrades from pages served by another origin are refused, so that other sites cannot control the game.
+func upgradeWebsocket(w http.ResponseWriter, r *http.Request) (*websocket, error) {
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin websocket upgrade", http.StatusForbidden)
+		return nil, errors.New("cross-origin websocket upgrade")
+	}
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "Expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("not a websocket upgrade")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Websockets are not supported", http.StatusInternalServerError)
+		return nil, errors.New("connection cannot be hijacked")
+	}
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(hash[:])
+	_, err = buffer.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err == nil {
+		err = buffer.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &websocket{
+		conn:   conn,
+		reader: buffer.Reader,
+	}, nil
+}
+
+// sameOrigin reports whether the Origin header of a request, if any, names the host the request was sent to.
+// Browsers always send the header with websocket upgrades, while other clients may leave it out.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// writeFrame sends a single unmasked frame, as servers must not mask their frames.
+func (ws *websocket) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMutex.Lock()
+	defer ws.writeMutex.Unlock()
+
+	header := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	_, err := ws.conn.Write(append(header, payload...))
+	return err
+}
+
+// WriteText sends a text message.
+func (ws *websocket) WriteText(message []byte) error {
+	return ws.writeFrame(opText, message)
+}
+
+// ReadText returns the next text message, answering pings on the way.
+// io.EOF is returned once the client closes the connection.
+func (ws *websocket) ReadText() (string, error) {
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return "", err
+		}
+		switch opcode {
+		case opText:
+			return string(payload), nil
+		case opPing:
+			err = ws.writeFrame(opPong, payload)
+			if err != nil {
+				return "", err
+			}
+		case opClose:
+			_ = ws.writeFrame(opClose, nil)
+			return "", io.EOF
+		}
+	}
+}
+
+func (ws *websocket) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(ws.reader, header)
+	if err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(ws.reader, extended)
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	// The viewer only ever sends single key presses.
+	if length > 1<<16 {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		_, err = io.ReadFull(ws.reader, mask)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(ws.reader, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return opcode, payload, nil
+}
+
+// Close sends a close frame and closes the connection.
+func (ws *websocket) Close() {
+	_ = ws.writeFrame(opClose, nil)
+	ws.conn.Close()
+}
@@ -0,0 +1,23 @@
+package headless
+
+import (
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Start runs without a window, for machines without a display. Events are printed in the same way as the
+// SDL loop prints them and key presses are read from stdin.
+func Start(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+	restore := util.RawTerminal()
+	defer restore()
+
+	go util.ReadKeys(keyPresses)
+
+	for event := range events {
+		if len(event.String()) > 0 {
+			fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
+		}
+	}
+}
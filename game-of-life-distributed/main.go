@@ -3,12 +3,30 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/headless"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/terminal"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
+// stringsFlag collects every value of a flag that can be given more than once.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 // main is the function called when starting Game of Life with 'go run .'
 func main() {
 	runtime.LockOSThread()
@@ -38,8 +56,173 @@ func main() {
 		10000000000,
 		"Specify the number of turns to process. Defaults to 10000000000.")
 
+	flag.StringVar(
+		&params.InputFile,
+		"input",
+		"",
+		"Specify a pgm, png, rle, cells or lif file to load the initial world from. A pgm or png image overrides -w and -h. Defaults to images/<w>x<h>.pgm.")
+
+	flag.StringVar(
+		&params.OutputDir,
+		"out",
+		"out",
+		"Specify the directory output images are written to. Defaults to out.")
+
+	flag.StringVar(
+		&params.Rule,
+		"rule",
+		"",
+		"Specify the rule in B/S notation, e.g. B36/S23. Defaults to the rule of an rle input file or B3/S23.")
+
+	flag.IntVar(
+		&params.PngScale,
+		"png",
+		0,
+		"Specify the size in pixels of a cell in png snapshots, which are written alongside the pgm output. Defaults to 0, which disables png output.")
+
+	threshold := flag.Int(
+		"threshold",
+		127,
+		"Specify the brightness (0-255) above which a pixel of a png input is alive. Defaults to 127.")
+
+	flag.StringVar(
+		&params.FilenameTemplate,
+		"name",
+		gol.DefaultFilenameTemplate,
+		"Specify how snapshots are named. {width}, {height}, {turn}, {time} and {session} are replaced. Defaults to "+gol.DefaultFilenameTemplate+".")
+
+	flag.IntVar(
+		&params.AutosaveEvery,
+		"autosave",
+		0,
+		"Specify how many turns apart snapshots are saved automatically. Defaults to 0, which disables autosaving.")
+
+	flag.IntVar(
+		&params.GifEvery,
+		"gifEvery",
+		1,
+		"Specify how many turns apart the frames of a gif recording are. Press r to start and stop recording. Defaults to 1.")
+
+	flag.DurationVar(
+		&params.GifDelay,
+		"gifDelay",
+		100*time.Millisecond,
+		"Specify how long each frame of a gif recording is shown for. Defaults to 100ms.")
+
+	flag.IntVar(
+		&params.GifMaxFrames,
+		"gifFrames",
+		500,
+		"Specify the number of frames after which a gif recording is saved. Defaults to 500.")
+
+	at := flag.String(
+		"at",
+		"",
+		"Specify where the top left corner of a pattern is placed as x,y. Defaults to the centre of the world.")
+
+	flag.BoolVar(
+		&params.CountEveryTurn,
+		"countEveryTurn",
+		false,
+		"Reports the number of alive cells after every turn instead of only every 2 seconds.")
+
+	flag.DurationVar(
+		&params.TickerPeriod,
+		"ticker",
+		2*time.Second,
+		"Specify how often the number of alive cells is reported. Press t to cycle through other periods. Defaults to 2s.")
+
+	flag.IntVar(
+		&params.TickerEvery,
+		"tickerEvery",
+		0,
+		"Specify a number of turns to report the number of alive cells after every that many turns instead of every -ticker period. Defaults to 0, which reports by time.")
+
+	flag.Float64Var(
+		&params.TurnsPerSecond,
+		"rate",
+		0,
+		"Specify the most turns to run a second. Press + and - to change it, and n to advance a single turn while paused. Defaults to 0, which runs as fast as possible.")
+
+	flag.Var(
+		(*stringsFlag)(&params.Breakpoints),
+		"break",
+		"Specify a breakpoint pausing the game when it becomes true: alive<N, alive>N, empty:X,Y,W,H, nonempty:X,Y,W,H, periodic or cell:X,Y. Can be given more than once.")
+
+	flag.IntVar(
+		&params.HistoryDepth,
+		"historyDepth",
+		100,
+		"Specify how many turns back the server looks for the same world to notice that it repeats. Defaults to 100.")
+
+	flag.BoolVar(
+		&params.FinishWhenStable,
+		"finishWhenStable",
+		false,
+		"Ends the game as soon as the world repeats, working out the final world from its period.")
+
+	flag.IntVar(
+		&params.RewindDepth,
+		"rewind",
+		100,
+		"Specify how many turns can be stepped back through while paused, with u for one turn and j for several. Defaults to 100.")
+
+	flag.IntVar(
+		&params.CheckpointEvery,
+		"checkpoints",
+		100,
+		"Specify how many turns apart the server starts out keeping worlds to compute past turns again from. Press w to save the world at a past turn. Defaults to 100.")
+
+	flag.StringVar(
+		&params.Server,
+		"server",
+		gol.DefaultServer,
+		"Specify the address of the server. Defaults to "+gol.DefaultServer+".")
+
+	flag.StringVar(
+		&params.ViewerAddr,
+		"viewer",
+		"",
+		"Specify an address such as :8080 to watch and control the game from a browser. Defaults to no viewer.")
+
+	flag.StringVar(
+		&params.Spectate,
+		"spectate",
+		"",
+		"Specify the ID of a running session to watch it without controlling it. The world and rule are taken from the session.")
+
+	noVis := flag.Bool(
+		"noVis",
+		false,
+		"Disables the SDL window. Events are printed and keys are read from stdin instead.")
+
+	term := flag.Bool(
+		"term",
+		false,
+		"Draws the world in the terminal instead of an SDL window. The arrow keys pan over large boards.")
+
 	flag.Parse()
 
+	if *threshold < 0 || *threshold > 255 {
+		fmt.Fprintln(os.Stderr, "-threshold must be between 0 and 255, got", *threshold)
+		os.Exit(2)
+	}
+	params.PngThreshold = uint8(*threshold)
+
+	if _, _, err := util.ParseRule(params.Rule); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *at != "" {
+		var offset util.Cell
+		_, err := fmt.Sscanf(*at, "%d,%d", &offset.X, &offset.Y)
+		util.Check(err)
+		params.PatternOffset = &offset
+	}
+
+	params = gol.ReadInputHeader(params)
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
@@ -49,5 +232,13 @@ func main() {
 
 	//time.Sleep(5 * time.Second)
 	gol.Run(params, events, keyPresses)
-	sdl.Start(params, events, keyPresses)
+	if *noVis {
+		headless.Start(params, events, keyPresses)
+	} else if *term {
+		terminal.Start(params, events, keyPresses)
+	} else {
+		// The window takes the key presses, leaving stdin free for typed commands such as "advance 500".
+		go util.ReadCommands(keyPresses)
+		sdl.Start(params, events, keyPresses)
+	}
 }
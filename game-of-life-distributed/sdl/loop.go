@@ -9,12 +9,29 @@ import (
 func Start(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
 	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
 
+	// typing is set while a breakpoint is typed after 'b'. Its characters, such as '<' and ':', are taken from the
+	// text SDL reports rather than from the keys, until enter ends it.
+	typing := false
+
 sdlLoop:
 	for {
 		event := w.PollEvent()
 		if event != nil {
 			switch e := event.(type) {
+			case *sdl.TextInputEvent:
+				if typing {
+					for _, key := range e.GetText() {
+						keyPresses <- key
+					}
+				}
 			case *sdl.KeyboardEvent:
+				if typing {
+					if e.Type == sdl.KEYDOWN && (e.Keysym.Sym == sdl.K_RETURN || e.Keysym.Sym == sdl.K_KP_ENTER) {
+						keyPresses <- '\n'
+						typing = false
+					}
+					break
+				}
 				switch e.Keysym.Sym {
 				case sdl.K_p:
 					keyPresses <- 'p'
@@ -24,6 +41,57 @@ sdlLoop:
 					keyPresses <- 'q'
 				case sdl.K_k:
 					keyPresses <- 'k'
+				case sdl.K_r:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'r'
+					}
+				case sdl.K_t:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 't'
+					}
+				case sdl.K_EQUALS, sdl.K_PLUS, sdl.K_KP_PLUS:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- '+'
+					}
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- '-'
+					}
+				case sdl.K_n:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'n'
+					}
+				case sdl.K_a:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'a'
+					}
+				case sdl.K_u:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'u'
+					}
+				case sdl.K_j:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'j'
+					}
+				case sdl.K_w:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'w'
+					}
+				case sdl.K_b:
+					// The text of the 'b' itself comes before its key is released, so typing starts after that.
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- 'b'
+					} else {
+						typing = true
+					}
+				case sdl.K_RETURN, sdl.K_KP_ENTER:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- '\n'
+					}
+				case sdl.K_0, sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6, sdl.K_7, sdl.K_8, sdl.K_9:
+					if e.Type == sdl.KEYDOWN {
+						keyPresses <- rune('0' + e.Keysym.Sym - sdl.K_0)
+					}
 				}
 			}
 		}
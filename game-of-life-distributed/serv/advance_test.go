@@ -0,0 +1,110 @@
+package serv_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestAdvance pauses a session, advances it by 10 turns with the 'a' prompt and checks that it pauses again
+// exactly 10 turns later, then resumes it to the end. The rate is limited so that the session cannot reach its last
+// turn before the first pause arrives, and nothing is sent until the session reports that it has paused.
+func TestAdvance(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100, Threads: 4, TurnsPerSecond: 50}
+	keyPresses := make(chan rune, 10)
+
+	var pauses []int
+	advancing := false
+	advancedTurns := 0
+	finished := false
+	runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 1 {
+				keyPresses <- 'p'
+			}
+			if advancing {
+				advancedTurns++
+			}
+		case gol.StateChange:
+			switch e.NewState {
+			case gol.Paused:
+				pauses = append(pauses, e.CompletedTurns)
+				if len(pauses) == 1 {
+					advancing = true
+					for _, key := range "a10\n" {
+						keyPresses <- key
+					}
+				} else {
+					advancing = false
+					keyPresses <- 'p'
+				}
+			}
+		case gol.FinalTurnComplete:
+			finished = e.CompletedTurns == p.Turns
+		}
+	})
+
+	if len(pauses) != 2 || pauses[1] != pauses[0]+10 {
+		t.Errorf("expected to pause twice 10 turns apart, paused at %v", pauses)
+	}
+	if advancedTurns != 10 {
+		t.Errorf("expected 10 turns while advancing, got %v", advancedTurns)
+	}
+	if !finished {
+		t.Errorf("expected the session to finish all %v turns after resuming", p.Turns)
+	}
+}
+
+// stepLastTurn pauses a session, advances it to the given turn and then steps a single turn with 'n',
+// returning the final turn the session reports. It fails the test if the session never finishes.
+func stepLastTurn(t *testing.T, p gol.Params, pauseAt int) int {
+	keyPresses := make(chan rune, 10)
+	finalTurn := make(chan int, 1)
+	go func() {
+		pauses := 0
+		runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+			switch e := event.(type) {
+			case gol.TurnComplete:
+				if e.CompletedTurns == 1 {
+					keyPresses <- 'p'
+				}
+			case gol.StateChange:
+				if e.NewState == gol.Paused {
+					pauses++
+					if pauses == 1 {
+						for _, key := range fmt.Sprintf("a%v\n", pauseAt-e.CompletedTurns) {
+							keyPresses <- key
+						}
+					} else if e.CompletedTurns == pauseAt {
+						keyPresses <- 'n'
+					}
+				}
+			case gol.FinalTurnComplete:
+				finalTurn <- e.CompletedTurns
+			}
+		})
+	}()
+
+	select {
+	case turn := <-finalTurn:
+		return turn
+	case <-time.After(30 * time.Second):
+		t.Fatalf("the session did not finish after stepping from turn %v", pauseAt)
+		return 0
+	}
+}
+
+// TestStepLastTurn steps a paused session through its last turn and checks that it finishes.
+func TestStepLastTurn(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100, Threads: 4, TurnsPerSecond: 50}
+	if turn := stepLastTurn(t, p, p.Turns-1); turn != p.Turns {
+		t.Errorf("expected the session to finish at turn %v, got %v", p.Turns, turn)
+	}
+}
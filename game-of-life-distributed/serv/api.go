@@ -0,0 +1,214 @@
+package serv
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// RunAPI serves the json control API on addr.
+//
+//	GET  /sessions                   lists every session
+//	GET  /sessions/<id>              returns the turn, alive cell count and state of a session
+//	POST /sessions/<id>/pause        pauses a session
+//	POST /sessions/<id>/resume       resumes a paused session
+//	POST /sessions/<id>/advance      runs a number of turns, given as {"turns": 500}, then pauses,
+//	                                 resuming the session first if it is paused
+//	POST /sessions/<id>/snapshot     sends the world to the controller to be saved
+//	POST /sessions/<id>/ticker       changes the AliveCellsCount period, given as {"period": "1s"},
+//	                                 or reports every so many turns instead, given as {"every": 100}
+//	POST /sessions/<id>/terminate    saves the world and ends the session
+//	POST /sessions/<id>/world        returns the world of a past turn, given as {"turn": 150}, computing it
+//	                                 again if need be, and sends it to the controller to be saved
+//
+// The actions are carried out by sending the session the same keys as the controller would.
+//
+// The API has no authentication, so anyone who can reach addr can control and terminate every session.
+// An address given as only a port, such as :8081, is therefore served on the loopback interface alone.
+// Give 0.0.0.0:8081 to serve it to other machines.
+func RunAPI(addr string) {
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	handler := APIHandler()
+	go func() {
+		err := http.ListenAndServe(addr, handler)
+		if err != nil {
+			log.Println("API error: ", err)
+		}
+	}()
+}
+
+// APIHandler returns the handler of the routes served by RunAPI.
+func APIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sessions", listSessions)
+	mux.HandleFunc("/sessions/", handleSession)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func listSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+
+	sessionsMutex.Lock()
+	var list []*session
+	for _, s := range sessions {
+		list = append(list, s)
+	}
+	sessionsMutex.Unlock()
+
+	statuses := []sessionStatus{}
+	for _, s := range list {
+		statuses = append(statuses, s.status())
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func handleSession(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/sessions/"), "/"), "/")
+	s := getSession(parts[0])
+	if s == nil {
+		writeError(w, http.StatusNotFound, "no session "+parts[0])
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "use GET")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.status())
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+
+	if parts[1] == "world" {
+		worldAt(w, r, s)
+		return
+	}
+
+	state := s.status().State
+	pressed := true
+	switch parts[1] {
+	case "pause":
+		if state == "Executing" {
+			pressed = s.press('p')
+		}
+	case "resume":
+		if state == "Paused" {
+			pressed = s.press('p')
+		}
+	case "advance":
+		var body struct {
+			Turns int `json:"turns"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil || body.Turns <= 0 {
+			writeError(w, http.StatusBadRequest, "expected {\"turns\": <turns>}")
+			return
+		}
+		s.setArgument(body.Turns)
+		pressed = s.press('a')
+	case "snapshot":
+		pressed = s.press('s')
+	case "terminate":
+		pressed = s.press('q')
+	case "ticker":
+		var body struct {
+			Period string `json:"period"`
+			Every  int    `json:"every"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil || (body.Period == "") == (body.Every == 0) {
+			writeError(w, http.StatusBadRequest, "expected {\"period\": \"<duration>\"} or {\"every\": <turns>}")
+			return
+		}
+		if body.Every < 0 {
+			writeError(w, http.StatusBadRequest, "invalid every "+strconv.Itoa(body.Every))
+			return
+		}
+		if body.Every > 0 {
+			s.ticker.setEvery(body.Every)
+			break
+		}
+		period, err := time.ParseDuration(body.Period)
+		if err != nil || period <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid period "+body.Period)
+			return
+		}
+		s.ticker.setPeriod(period)
+	default:
+		writeError(w, http.StatusNotFound, "unknown action "+parts[1])
+		return
+	}
+
+	if !pressed {
+		writeError(w, http.StatusServiceUnavailable, "session is busy")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.status())
+}
+
+// worldResponse is the world of a past turn, as returned by the control API. Cells are [x, y] pairs.
+type worldResponse struct {
+	Turn       int      `json:"turn"`
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	AliveCells int      `json:"aliveCells"`
+	Hash       string   `json:"hash"`
+	Cells      [][2]int `json:"cells"`
+}
+
+func worldAt(w http.ResponseWriter, r *http.Request, s *session) {
+	var body struct {
+		Turn *int `json:"turn"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.Turn == nil {
+		writeError(w, http.StatusBadRequest, "expected {\"turn\": <turn>}")
+		return
+	}
+
+	world, err := s.worldAt(*body.Turn)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sendWorldAt(s.conn, *body.Turn, world, nil)
+
+	cells := [][2]int{}
+	for _, cell := range getCurrentAliveCells(world) {
+		cells = append(cells, [2]int{cell.X, cell.Y})
+	}
+	writeJSON(w, http.StatusOK, worldResponse{
+		Turn:       *body.Turn,
+		Width:      s.p.ImageWidth,
+		Height:     s.p.ImageHeight,
+		AliveCells: len(cells),
+		Hash:       fmt.Sprintf("%016x", util.WorldHash(world)),
+		Cells:      cells,
+	})
+}
@@ -0,0 +1,161 @@
+package serv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/serv"
+)
+
+// apiStatus holds the fields of a session status checked by the API tests.
+type apiStatus struct {
+	ID           string `json:"id"`
+	Turn         int    `json:"turn"`
+	State        string `json:"state"`
+	TickerPeriod string `json:"tickerPeriod"`
+	TickerEvery  int    `json:"tickerEvery"`
+}
+
+// request sends a request to the API served by api and returns the status code and the body.
+func request(t *testing.T, api *httptest.Server, method, path, body string) (int, []byte) {
+	r, err := http.NewRequest(method, api.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := api.Client().Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var data json.RawMessage
+	_ = json.NewDecoder(response.Body).Decode(&data)
+	return response.StatusCode, data
+}
+
+// expectStatus sends a request and reports an error unless it is answered with the given status code.
+func expectStatus(t *testing.T, api *httptest.Server, method, path, body string, expected int) []byte {
+	status, data := request(t, api, method, path, body)
+	if status != expected {
+		t.Errorf("%v %v %v: expected status %v, got %v %s", method, path, body, expected, status, data)
+	}
+	return data
+}
+
+// waitForStatus polls the status of a session until done returns true for it, failing the test after 10 seconds.
+func waitForStatus(t *testing.T, api *httptest.Server, id string, done func(s apiStatus) bool) apiStatus {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var s apiStatus
+		status, data := request(t, api, http.MethodGet, "/sessions/"+id, "")
+		if status == http.StatusOK {
+			_ = json.Unmarshal(data, &s)
+			if done(s) {
+				return s
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session %v never reached the expected status, last %+v", id, s)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestAPI drives a session through every route of the control API,
+// including the answers to unknown sessions and actions, wrong methods and bad bodies.
+func TestAPI(t *testing.T) {
+	runServer()
+	api := httptest.NewServer(serv.APIHandler())
+	defer api.Close()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 1000000000, Threads: 4, SessionID: "api"}
+	snapshots := make(chan int, 10)
+	finished := make(chan bool)
+	go func() {
+		runEvents(p, func(event gol.Event) {
+			if e, ok := event.(gol.ImageOutputComplete); ok {
+				snapshots <- e.CompletedTurns
+			}
+		})
+		close(finished)
+	}()
+
+	waitForStatus(t, api, p.SessionID, func(s apiStatus) bool { return s.Turn > 0 })
+	session := "/sessions/" + p.SessionID
+
+	var list []apiStatus
+	_ = json.Unmarshal(expectStatus(t, api, http.MethodGet, "/sessions", "", http.StatusOK), &list)
+	listed := false
+	for _, s := range list {
+		listed = listed || s.ID == p.SessionID
+	}
+	if !listed {
+		t.Errorf("expected session %v to be listed, got %+v", p.SessionID, list)
+	}
+	expectStatus(t, api, http.MethodPost, "/sessions", "", http.StatusMethodNotAllowed)
+
+	expectStatus(t, api, http.MethodGet, "/sessions/nosuchsession", "", http.StatusNotFound)
+	expectStatus(t, api, http.MethodPost, "/sessions/nosuchsession/pause", "", http.StatusNotFound)
+	expectStatus(t, api, http.MethodPost, session, "", http.StatusMethodNotAllowed)
+	expectStatus(t, api, http.MethodGet, session+"/pause", "", http.StatusMethodNotAllowed)
+	expectStatus(t, api, http.MethodPost, session+"/explode", "", http.StatusNotFound)
+
+	expectStatus(t, api, http.MethodPost, session+"/pause", "", http.StatusAccepted)
+	paused := waitForStatus(t, api, p.SessionID, func(s apiStatus) bool { return s.State == "Paused" })
+
+	expectStatus(t, api, http.MethodPost, session+"/advance", "", http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/advance", `{"turns": 0}`, http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/advance", `{"turns": 10}`, http.StatusAccepted)
+	advanced := waitForStatus(t, api, p.SessionID, func(s apiStatus) bool { return s.State == "Paused" && s.Turn > paused.Turn })
+	if advanced.Turn != paused.Turn+10 {
+		t.Errorf("expected advancing by 10 turns from turn %v to pause at turn %v, got %v", paused.Turn, paused.Turn+10, advanced.Turn)
+	}
+
+	expectStatus(t, api, http.MethodPost, session+"/world", "", http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/world", `{"turn": 2000000000}`, http.StatusBadRequest)
+	var world struct {
+		Turn int `json:"turn"`
+	}
+	_ = json.Unmarshal(expectStatus(t, api, http.MethodPost, session+"/world", `{"turn": 1}`, http.StatusOK), &world)
+	if world.Turn != 1 {
+		t.Errorf("expected the world after turn 1, got turn %v", world.Turn)
+	}
+	if turn := <-snapshots; turn != 1 {
+		t.Errorf("expected the world after turn 1 to be saved, got turn %v", turn)
+	}
+
+	expectStatus(t, api, http.MethodPost, session+"/snapshot", "", http.StatusAccepted)
+	if turn := <-snapshots; turn != advanced.Turn {
+		t.Errorf("expected a snapshot of turn %v, got turn %v", advanced.Turn, turn)
+	}
+
+	expectStatus(t, api, http.MethodPost, session+"/ticker", `{}`, http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/ticker", `{"period": "1s", "every": 100}`, http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/ticker", `{"period": "soon"}`, http.StatusBadRequest)
+	expectStatus(t, api, http.MethodPost, session+"/ticker", `{"every": -1}`, http.StatusBadRequest)
+	var ticker apiStatus
+	_ = json.Unmarshal(expectStatus(t, api, http.MethodPost, session+"/ticker", `{"period": "1s"}`, http.StatusAccepted), &ticker)
+	if ticker.TickerPeriod != "1s" {
+		t.Errorf("expected a ticker period of 1s, got %v", ticker.TickerPeriod)
+	}
+	_ = json.Unmarshal(expectStatus(t, api, http.MethodPost, session+"/ticker", `{"every": 100}`, http.StatusAccepted), &ticker)
+	if ticker.TickerEvery != 100 {
+		t.Errorf("expected a ticker every 100 turns, got %v", ticker.TickerEvery)
+	}
+
+	expectStatus(t, api, http.MethodPost, session+"/resume", "", http.StatusAccepted)
+	waitForStatus(t, api, p.SessionID, func(s apiStatus) bool { return s.State == "Executing" && s.Turn > advanced.Turn })
+
+	expectStatus(t, api, http.MethodPost, session+"/terminate", "", http.StatusAccepted)
+	select {
+	case <-finished:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the session did not end after being terminated")
+	}
+	expectStatus(t, api, http.MethodGet, session, "", http.StatusNotFound)
+}
@@ -0,0 +1,116 @@
+package serv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// breakpoint pauses a session when its condition becomes true. It is written as one of
+//
+//	alive<N            fewer than N cells are alive
+//	alive>N            more than N cells are alive
+//	empty:X,Y,W,H      the W x H region with its top left corner at X,Y has no alive cells
+//	nonempty:X,Y,W,H   the region has alive cells
+//	periodic           the world is the same as it was a few turns earlier
+//	cell:X,Y           the cell at X,Y changes state
+//
+// Only a change from false to true is a hit, so a session resumed while a condition still holds runs on.
+type breakpoint struct {
+	spec      string
+	kind      string
+	threshold int
+	x, y      int
+	width     int
+	height    int
+
+	// holds is whether the condition held after the last turn checked.
+	holds bool
+	cell  uint8
+}
+
+func parseBreakpoint(spec string) (*breakpoint, error) {
+	b := &breakpoint{spec: spec}
+	var err error
+	switch {
+	case strings.HasPrefix(spec, "alive<"), strings.HasPrefix(spec, "alive>"):
+		b.kind = spec[:6]
+		b.threshold, err = strconv.Atoi(spec[6:])
+	case strings.HasPrefix(spec, "empty:"), strings.HasPrefix(spec, "nonempty:"):
+		parts := strings.SplitN(spec, ":", 2)
+		b.kind = parts[0]
+		_, err = fmt.Sscanf(parts[1], "%d,%d,%d,%d", &b.x, &b.y, &b.width, &b.height)
+		if err == nil && (b.width <= 0 || b.height <= 0) {
+			err = fmt.Errorf("the region must not be empty")
+		}
+		if err == nil && (b.x < 0 || b.y < 0) {
+			err = fmt.Errorf("the coordinates must not be negative")
+		}
+	case spec == "periodic":
+		b.kind = spec
+	case strings.HasPrefix(spec, "cell:"):
+		b.kind = "cell"
+		_, err = fmt.Sscanf(spec[5:], "%d,%d", &b.x, &b.y)
+		if err == nil && (b.x < 0 || b.y < 0) {
+			err = fmt.Errorf("the coordinates must not be negative")
+		}
+	default:
+		err = fmt.Errorf("unknown condition")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid breakpoint %v: %v", spec, err)
+	}
+	return b, nil
+}
+
+// check evaluates the breakpoint on the world after a turn, where period is how many turns ago the world was last
+// the same, or 0. It returns whether the breakpoint was hit and why.
+func (b *breakpoint) check(latest turnState, period int) (bool, string) {
+	held := b.holds
+	reason := ""
+	switch b.kind {
+	case "alive<":
+		b.holds = latest.alive < b.threshold
+		reason = fmt.Sprintf("%v alive cells, fewer than %v", latest.alive, b.threshold)
+	case "alive>":
+		b.holds = latest.alive > b.threshold
+		reason = fmt.Sprintf("%v alive cells, more than %v", latest.alive, b.threshold)
+	case "empty", "nonempty":
+		count := b.regionCount(latest.world)
+		b.holds = (count == 0) == (b.kind == "empty")
+		reason = fmt.Sprintf("%v alive cells in the %vx%v region at %v,%v", count, b.width, b.height, b.x, b.y)
+	case "periodic":
+		b.holds = period > 0
+		reason = fmt.Sprintf("the world repeats every %v turns", period)
+	case "cell":
+		cell := latest.world[mod(b.x, len(latest.world))][mod(b.y, len(latest.world[0]))]
+		// For a cell, holds is whether its state has been seen, so that it is not hit when it is added.
+		changed := b.holds && cell != b.cell
+		b.holds, b.cell = true, cell
+		if cell == alive {
+			reason = fmt.Sprintf("cell %v,%v was born", b.x, b.y)
+		} else {
+			reason = fmt.Sprintf("cell %v,%v died", b.x, b.y)
+		}
+		return changed, reason
+	}
+	return b.holds && !held, reason
+}
+
+// regionCount counts the alive cells in the region of the breakpoint, which wraps around the edges of the world.
+func (b *breakpoint) regionCount(world [][]byte) int {
+	count := 0
+	for i := 0; i < b.width; i++ {
+		column := world[mod(b.x+i, len(world))]
+		for j := 0; j < b.height; j++ {
+			if column[mod(b.y+j, len(column))] == alive {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func breakpointHitString(turn int, spec string, reason string) string {
+	return sendBreakpointHitCode + strconv.Itoa(turn) + "\n" + spec + "\n" + reason + "\n"
+}
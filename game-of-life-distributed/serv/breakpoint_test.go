@@ -0,0 +1,126 @@
+package serv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBreakpoints runs a world once to find when the conditions of some breakpoints first become true,
+// then runs it again with those breakpoints, resuming every time the session pauses,
+// and checks that each is first hit on the expected turn.
+func TestBreakpoints(t *testing.T) {
+	runServer()
+
+	const turns = 150
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: turns, Threads: 4, CountEveryTurn: true}
+
+	alive := make(map[int]int)
+	firstFlip := make(map[util.Cell]int)
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.AliveCellsCount:
+			alive[e.CompletedTurns] = e.CellsCount
+		case gol.CellFlipped:
+			if _, ok := firstFlip[e.Cell]; !ok && e.CompletedTurns > 0 {
+				firstFlip[e.Cell] = e.CompletedTurns
+			}
+		}
+	})
+
+	// The first count after turn 10 higher than any since turn 1 is where alive> with the highest of them is hit.
+	// The condition already holds for the initial world, but only becoming true again counts.
+	expected := make(map[string]int)
+	highest := alive[1]
+	for turn := 2; turn <= turns; turn++ {
+		if alive[turn] > highest && turn > 10 {
+			expected[fmt.Sprintf("alive>%v", highest)] = turn
+			break
+		}
+		if alive[turn] > highest {
+			highest = alive[turn]
+		}
+	}
+	for cell, turn := range firstFlip {
+		if turn > 20 {
+			expected[fmt.Sprintf("cell:%v,%v", cell.X, cell.Y)] = turn
+			break
+		}
+	}
+	if len(expected) != 2 {
+		t.Fatalf("the world did not change as expected: %v", expected)
+	}
+
+	// A glider on a 16x16 world is back where it started after 64 turns.
+	glider := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100, Threads: 4, Breakpoints: []string{"periodic"}}
+
+	for _, run := range []struct {
+		p        gol.Params
+		expected map[string]int
+	}{
+		{p, expected},
+		{glider, map[string]int{"periodic": 64}},
+	} {
+		run.p.CountEveryTurn = false
+		for spec := range run.expected {
+			if spec != "periodic" {
+				run.p.Breakpoints = append(run.p.Breakpoints, spec)
+			}
+		}
+
+		keyPresses := make(chan rune, 10)
+		hits := make(map[string]int)
+		var pauses []int
+		runEventsWithKeys(run.p, keyPresses, func(event gol.Event) {
+			switch e := event.(type) {
+			case gol.BreakpointHit:
+				if _, ok := hits[e.Breakpoint]; !ok {
+					hits[e.Breakpoint] = e.CompletedTurns
+				}
+			case gol.StateChange:
+				if e.NewState == gol.Paused {
+					pauses = append(pauses, e.CompletedTurns)
+					keyPresses <- 'p'
+				}
+			}
+		})
+
+		for spec, turn := range run.expected {
+			if hits[spec] != turn {
+				t.Errorf("%v: expected to be hit first at turn %v, got %v", spec, turn, hits[spec])
+			}
+			found := false
+			for _, pause := range pauses {
+				found = found || pause == turn
+			}
+			if !found {
+				t.Errorf("%v: expected to pause at turn %v, paused at %v", spec, turn, pauses)
+			}
+		}
+	}
+}
+
+// TestInvalidBreakpoints starts a session with breakpoints that cannot be parsed and checks that they are
+// ignored rather than stopping the server.
+func TestInvalidBreakpoints(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 10, Threads: 4,
+		Breakpoints: []string{"cell:-100,0", "empty:-100,0,4,4", "nonempty:0,-1,4,4", "alive<x"}}
+	final := 0
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.BreakpointHit:
+			t.Errorf("invalid breakpoint %v was hit", e.Breakpoint)
+		case gol.FinalTurnComplete:
+			final = e.CompletedTurns
+		}
+	})
+
+	if final != p.Turns {
+		t.Errorf("expected the session to finish at turn %v, got %v", p.Turns, final)
+	}
+}
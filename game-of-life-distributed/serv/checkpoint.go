@@ -0,0 +1,102 @@
+package serv
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// checkpoints keeps the worlds of some of the past turns, so that the world of any past turn can be computed
+// again from the nearest one before it. Worlds are never modified once a turn is complete, so they are not copied.
+// When there are too many, every other one is forgotten and they are kept twice as far apart,
+// so that a long run is still covered from its first turn.
+type checkpoints struct {
+	mutex  sync.Mutex
+	every  int
+	worlds map[int][][]byte
+}
+
+// defaultCheckpointEvery is how many turns apart checkpoints start out when the controller does not choose.
+const defaultCheckpointEvery = 100
+
+// maxCheckpoints is the number of worlds kept before they are thinned out.
+const maxCheckpoints = 64
+
+func newCheckpoints(every int, world [][]byte) *checkpoints {
+	if every <= 0 {
+		every = defaultCheckpointEvery
+	}
+	return &checkpoints{
+		every:  every,
+		worlds: map[int][][]byte{0: world},
+	}
+}
+
+// add keeps the world of a turn if a checkpoint is due.
+func (c *checkpoints) add(turn int, world [][]byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if turn%c.every != 0 {
+		return
+	}
+	c.worlds[turn] = world
+	if len(c.worlds) > maxCheckpoints {
+		c.every *= 2
+		for t := range c.worlds {
+			if t%c.every != 0 {
+				delete(c.worlds, t)
+			}
+		}
+	}
+}
+
+// nearest returns the latest checkpoint at or before a turn.
+func (c *checkpoints) nearest(turn int) (int, [][]byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	nearest := 0
+	for t := range c.worlds {
+		if t <= turn && t > nearest {
+			nearest = t
+		}
+	}
+	return nearest, c.worlds[nearest]
+}
+
+// worldAt computes the world of a past turn again from the nearest checkpoint before it.
+// The running session is not disturbed, apart from sharing the processor.
+// Once the world is stabilised, a turn is first mapped back into the first cycle, so that the turns skipped with
+// FinishWhenStable, which have no checkpoints, are answered without computing them all.
+func (s *session) worldAt(turn int) ([][]byte, error) {
+	latest := s.snapshot()
+	if turn < 0 || turn > latest.turn {
+		return nil, fmt.Errorf("turn %v has not been reached, the latest is %v", turn, latest.turn)
+	}
+	if turn == latest.turn {
+		return latest.world, nil
+	}
+	if from, period := s.cycle(); period > 0 && turn > from {
+		turn = from + (turn-from)%period
+	}
+
+	start, world := s.checkpoints.nearest(turn)
+	r, _ := parseRule(s.p.Rule)
+	for t := start; t < turn; t++ {
+		world, _, _ = calculateDistributedStep(s.p, r, t, world)
+	}
+	return world, nil
+}
+
+// sendWorldAt sends the controller the world of a past turn to be saved, or why it cannot be.
+func sendWorldAt(conn *net.Conn, turn int, world [][]byte, err error) {
+	worldAtString := sendWorldAtCode + strconv.Itoa(turn) + "\n"
+	if err != nil {
+		worldAtString += err.Error() + "\n\n"
+	} else {
+		worldAtString += "\n" + cellsToString(getCurrentAliveCells(world))
+	}
+	fmt.Fprint(*conn, worldAtString)
+}
@@ -0,0 +1,81 @@
+package serv_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// postWorld asks the control API for the world of a session at a past turn.
+func postWorld(id string, turn int) (int, []util.Cell) {
+	body, _ := json.Marshal(map[string]int{"turn": turn})
+	response, err := http.Post("http://"+apiAddr+"/sessions/"+id+"/world", "application/json", bytes.NewReader(body))
+	util.Check(err)
+	defer response.Body.Close()
+
+	var world struct {
+		Cells [][2]int `json:"cells"`
+	}
+	_ = json.NewDecoder(response.Body).Decode(&world)
+	var cells []util.Cell
+	for _, cell := range world.Cells {
+		cells = append(cells, util.Cell{X: cell[0], Y: cell[1]})
+	}
+	return response.StatusCode, cells
+}
+
+// TestWorldAt pauses a session after turn 200 and asks for the world after turn 150, through the control API and
+// with 'w', checking it against a session run for 150 turns. A checkpoint every turn makes sure they have been thinned out.
+func TestWorldAt(t *testing.T) {
+	runServer()
+	runAPI()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 150, Threads: 4}
+	expected := runSession(p)
+
+	p = gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 10000, Threads: 4, SessionID: "worldat", CheckpointEvery: 1}
+	keyPresses := make(chan rune, 10)
+	paused := false
+	saved := 0
+	runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 200 && !paused {
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			if e.NewState != gol.Paused {
+				break
+			}
+			paused = true
+			status, cells := postWorld(p.SessionID, 150)
+			if status != http.StatusOK || !sameCells(cells, expected) {
+				t.Errorf("world after turn 150 from the API differs (status %v):\n%v", status, util.AliveCellsToString(cells, expected, p.ImageWidth, p.ImageHeight))
+			}
+			if status, _ := postWorld(p.SessionID, p.Turns); status != http.StatusBadRequest {
+				t.Errorf("expected a turn that has not been reached to be refused, got status %v", status)
+			}
+			for _, key := range "w150\n" {
+				keyPresses <- key
+			}
+		case gol.ImageOutputComplete:
+			if e.CompletedTurns == 150 {
+				saved++
+				if e.Filename != "64x64x150" {
+					t.Errorf("expected the world to be saved as 64x64x150, got %v", e.Filename)
+				}
+				if saved == 2 {
+					keyPresses <- 'q'
+				}
+			}
+		}
+	})
+
+	if saved != 2 {
+		t.Errorf("expected the world after turn 150 to be saved twice, got %v", saved)
+	}
+}
@@ -0,0 +1,48 @@
+package serv
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestWorldAtSkippedTurn checks that once a session has skipped to its last turn with FinishWhenStable,
+// the world of a turn it skipped over is worked out from the cycle rather than computed turn by turn.
+func TestWorldAtSkippedTurn(t *testing.T) {
+	// A glider on a 16x16 world is back where it started every 64 turns.
+	p := Params{ImageWidth: 16, ImageHeight: 16, Threads: 4, Turns: 1000000010}
+	world := createWorldAliveCells(p, []Cell{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}})
+	s := newSession("skipped", p, world)
+	r, _ := parseRule(p.Rule)
+
+	// The session stabilises at turn 64 and skips from turn 74 to its last turn, as the distributor does.
+	worlds := [][][]byte{world}
+	for turn := 0; turn < 74; turn++ {
+		world, _, _ = calculateDistributedStep(p, r, turn, world)
+		worlds = append(worlds, world)
+		s.checkpoints.add(turn+1, world)
+	}
+	s.setCycle(0, 64)
+	latest := newTurnState(world)
+	latest.turn = p.Turns
+	s.update(latest, "")
+
+	// 1000000005 is 5 turns into a cycle, as 1000000000 is a multiple of 64.
+	start := time.Now()
+	skipped, err := s.worldAt(1000000005)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("expected the world of a skipped turn to be found from the cycle, took %v", time.Since(start))
+	}
+	if util.WorldHash(skipped) != util.WorldHash(worlds[5]) {
+		t.Errorf("expected the world after turn 1000000005 to be the world after turn 5 %v, got %v",
+			getCurrentAliveCells(worlds[5]), getCurrentAliveCells(skipped))
+	}
+
+	if _, err := s.worldAt(p.Turns + 1); err == nil {
+		t.Errorf("expected a turn after the last turn to be refused")
+	}
+}
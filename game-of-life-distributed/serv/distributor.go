@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,6 +26,13 @@ const sendCloseProgramCode = "4\n"
 const sendPauseProgramCode = "5\n"
 const sendExecuteProgramCode = "6\n"
 const sendTurnCompleteCode = "7\n"
+const sendRejectedCode = "8\n"
+const sendWorldCode = "9\n"
+const sendThroughputCode = "10\n"
+const sendSpeedCode = "11\n"
+const sendBreakpointHitCode = "12\n"
+const sendStabilisedCode = "13\n"
+const sendWorldAtCode = "14\n"
 
 ////////////////////////////////////
 
@@ -66,8 +75,32 @@ func calculateNeighbours(x, y int, world [][]uint8) int {
 	return neighbours
 }
 
-func calculateNextWorld(chunk chan [][]uint8, turn int, offset int) {
+// stripChanges describes how a strip of the world, or the whole world, changed during a turn.
+type stripChanges struct {
+	// flipped holds the cells that changed state, in world coordinates.
+	flipped []Cell
+	births  int
+	deaths  int
+
+	// compute and finished are only set for a single strip. They are how long the worker took and when it was done.
+	compute  time.Duration
+	finished time.Time
+}
+
+// add combines the changes of two strips.
+func (c stripChanges) add(other stripChanges) stripChanges {
+	return stripChanges{
+		flipped: append(c.flipped, other.flipped...),
+		births:  c.births + other.births,
+		deaths:  c.deaths + other.deaths,
+	}
+}
+
+// calculateNextWorld computes the next state of a strip of the world, which arrives with a halo row on either side.
+// The changes to the strip are reported on changes.
+func calculateNextWorld(chunk chan [][]uint8, changes chan<- stripChanges, turn int, offset int, r rule) {
 	world := <-chunk
+	start := time.Now()
 
 	height := len(world)
 	width := len(world[0])
@@ -77,41 +110,34 @@ func calculateNextWorld(chunk chan [][]uint8, turn int, offset int) {
 		newWorld[i] = make([]byte, width)
 	}
 
+	var strip stripChanges
 	for x := 1; x < height-1; x++ {
 		for y := 0; y < width; y++ {
 			neighbours := calculateNeighbours(x, y, world)
-			if world[x][y] == alive {
-				if neighbours == 2 || neighbours == 3 {
-					newWorld[x][y] = alive
+			newWorld[x][y] = r.next(world[x][y], neighbours)
+			if newWorld[x][y] != world[x][y] {
+				strip.flipped = append(strip.flipped, Cell{X: x + offset - 1, Y: y})
+				if newWorld[x][y] == alive {
+					strip.births++
 				} else {
-					//Send information to the controller
-					// c.events <- CellFlipped{
-					// 	CompletedTurns: turn,
-					// 	Cell:           util.Cell{X: x + offset - 1, Y: y},
-					// }
-					newWorld[x][y] = dead
-				}
-			} else {
-				if neighbours == 3 {
-					//Send infrmation to controller
-					// c.events <- CellFlipped{
-					// 	CompletedTurns: turn,
-					// 	Cell:           util.Cell{X: x + offset - 1, Y: y},
-					// }
-					newWorld[x][y] = alive
-				} else {
-					newWorld[x][y] = dead
+					strip.deaths++
 				}
 			}
 		}
 	}
 	newWorld = newWorld[1:(height - 1)]
+	strip.compute = time.Since(start)
+	strip.finished = time.Now()
 	chunk <- newWorld
+	changes <- strip
 }
 
-func calculateDistributedStep(p Params, turn int, world [][]uint8) [][]uint8 {
+// calculateDistributedStep splits the world between p.Threads workers and returns the next world
+// together with the changes of every strip combined and how each worker spent the turn.
+func calculateDistributedStep(p Params, r rule, turn int, world [][]uint8) ([][]uint8, stripChanges, []workerTiming) {
 
 	chunk := make([]chan [][]byte, p.Threads)
+	changes := make([]chan stripChanges, p.Threads)
 	worldsChunk := make([][][]uint8, p.Threads)
 
 	chunkWidth := p.ImageWidth / p.Threads
@@ -123,7 +149,8 @@ func calculateDistributedStep(p Params, turn int, world [][]uint8) [][]uint8 {
 		//Making the world for the first thread
 		worldsChunk[0] = append([][]byte{world[p.ImageWidth-1]}, world[0:chunkWidth+1]...)
 		// Making the world for the last thread (if there is more than one thread)
-		worldsChunk[p.Threads-1] = append(world[((p.Threads-1)*chunkWidth-1):], [][]byte{world[0]}...)
+		// The capacity is limited so that appending never writes into the world, which observers may be reading.
+		worldsChunk[p.Threads-1] = append(world[((p.Threads-1)*chunkWidth-1):p.ImageWidth:p.ImageWidth], [][]byte{world[0]}...)
 	}
 
 	var newWorld [][]byte
@@ -135,15 +162,27 @@ func calculateDistributedStep(p Params, turn int, world [][]uint8) [][]uint8 {
 		}
 		offset := i * chunkWidth
 		chunk[i] = make(chan [][]byte)
-		go calculateNextWorld(chunk[i], turn, offset)
+		changes[i] = make(chan stripChanges)
+		go calculateNextWorld(chunk[i], changes[i], turn, offset, r)
 		chunk[i] <- worldsChunk[i]
 	}
 
+	var worldChanges stripChanges
+	strips := make([]stripChanges, p.Threads)
 	for i := 0; i < p.Threads; i++ {
 		newWorld = append(newWorld, <-chunk[i]...)
+		strips[i] = <-changes[i]
+		worldChanges = worldChanges.add(strips[i])
+	}
+
+	// Every worker waits at the barrier from when it finishes until the last strip has been collected.
+	barrier := time.Now()
+	timings := make([]workerTiming, p.Threads)
+	for i, strip := range strips {
+		timings[i] = workerTiming{compute: strip.compute, wait: barrier.Sub(strip.finished)}
 	}
 
-	return newWorld
+	return newWorld, worldChanges, timings
 }
 
 func sendCloseProgram(conn *net.Conn, turn *int) {
@@ -161,151 +200,427 @@ func sendExecutingProgram(conn *net.Conn, turn *int) {
 	fmt.Fprintf(*conn, executeString)
 }
 
+// keyAction is what the distributor does after handling the key presses waiting for it.
+type keyAction int
+
+const (
+	keepRunning keyAction = iota
+	stepTurn
+	quitProgram
+)
+
 //Receive key presses from controller
-func manageSdlInput(p Params, conn *net.Conn, keyPresses <-chan rune, turn *int, world *[][]uint8, done chan bool, ticker *ticker) bool {
+func manageSdlInput(p Params, conn *net.Conn, s *session, turn *int, world *[][]uint8, done chan bool, autosaves *sync.WaitGroup, rewinds *rewindBuffer) keyAction {
+	ticker := s.ticker
+	if pauseAt := s.getPauseAt(); pauseAt > 0 && *turn >= pauseAt {
+		fmt.Println("Pausing at turn", *turn)
+		pauseProgram(conn, s, turn, done)
+		return managePausedInput(conn, s, turn, world, done, autosaves, rewinds)
+	}
 	select {
-	case key := <-keyPresses:
-		if key == 's' {
-			sendWritePgm(conn, *turn, *world)
-		} else if key == 'q' {
+	case key := <-s.keyPresses:
+		if key == 'q' {
+			closeProgramm(*turn, done, ticker)
 			sendWritePgm(conn, *turn, *world)
+			autosaves.Wait()
 			sendCloseProgram(conn, turn)
-			closeProgramm(*turn, done, ticker)
-			return true
+			return quitProgram
 		} else if key == 'p' {
-			ticker.stopTicker(done)
-			sendPauseProgram(conn, turn)
-			resume := 'N'
-			for resume != 'p' {
-				resume = <-keyPresses
-				if resume == 's' {
-					sendWritePgm(conn, *turn, *world)
-				} else if resume == 'q' {
-					sendWritePgm(conn, *turn, *world)
-					sendCloseProgram(conn, turn)
-					return true
-				}
-			}
-			ticker.resetTicker(conn, turn, world, done)
-			fmt.Println("Continuing")
-			sendExecutingProgram(conn, turn)
+			pauseProgram(conn, s, turn, done)
+			return managePausedInput(conn, s, turn, world, done, autosaves, rewinds)
+		} else if key == 'a' {
+			advanceProgram(s, *turn)
+		} else {
+			manageCommonKey(key, conn, s, turn, world)
 		}
 	default:
+		return keepRunning
+	}
+	return keepRunning
+}
+
+// managePausedInput waits for key presses while the session is paused, until it is resumed or quit
+// or 'n' asks for a single turn to be computed. The session is still paused after that turn.
+// 'u' steps the session back a turn and 'j' jumps back the number of turns sent with it.
+func managePausedInput(conn *net.Conn, s *session, turn *int, world *[][]uint8, done chan bool, autosaves *sync.WaitGroup, rewinds *rewindBuffer) keyAction {
+	for {
+		key := <-s.keyPresses
+		if key == 'p' {
+			resumeProgram(conn, s, turn, done)
+			return keepRunning
+		} else if key == 'a' {
+			if advanceProgram(s, *turn) {
+				resumeProgram(conn, s, turn, done)
+				return keepRunning
+			}
+		} else if key == 'n' {
+			return stepTurn
+		} else if key == 'u' {
+			rewindProgram(conn, s, rewinds, 1, turn, world)
+		} else if key == 'j' {
+			rewindProgram(conn, s, rewinds, s.takeArgument(), turn, world)
+		} else if key == 'q' {
+			sendWritePgm(conn, *turn, *world)
+			autosaves.Wait()
+			sendCloseProgram(conn, turn)
+			return quitProgram
+		}
+		manageCommonKey(key, conn, s, turn, world)
+	}
+}
+
+// pauseProgram stops the ticker and tells the controller the session is paused.
+// Any turn the session was going to pause at is forgotten.
+func pauseProgram(conn *net.Conn, s *session, turn *int, done chan bool) {
+	s.setPauseAt(0)
+	s.ticker.stopTicker(done)
+	s.setState("Paused")
+	sendPauseProgram(conn, turn)
+}
+
+func resumeProgram(conn *net.Conn, s *session, turn *int, done chan bool) {
+	s.throughput.restart()
+	s.ticker.resetTicker(conn, s, done)
+	s.setState("Executing")
+	fmt.Println("Continuing")
+	sendExecutingProgram(conn, turn)
+}
+
+// advanceProgram makes the session pause again once it has run the number of turns sent with 'a'.
+// It returns false if no number of turns was sent.
+func advanceProgram(s *session, turn int) bool {
+	turns := s.takeArgument()
+	if turns <= 0 {
 		return false
 	}
-	return false
+	s.setPauseAt(turn + turns)
+	fmt.Println("Pausing at turn", turn+turns)
+	return true
+}
+
+// manageCommonKey handles the keys that do the same whether or not the session is paused.
+func manageCommonKey(key rune, conn *net.Conn, s *session, turn *int, world *[][]uint8) {
+	if key == 's' {
+		sendWritePgm(conn, *turn, *world)
+	} else if key == 'y' {
+		sendWorld(conn, *turn, *world)
+	} else if key == 't' {
+		fmt.Println("Ticker period set to", s.ticker.nextPeriod())
+	} else if key == '+' {
+		sendSpeed(conn, s, *turn, s.limiter.faster())
+	} else if key == '-' {
+		sendSpeed(conn, s, *turn, s.limiter.slower())
+	}
+}
+
+// sendSpeed tells the controller and the spectators the target rate of the session has changed.
+func sendSpeed(conn *net.Conn, s *session, turn int, rate float64) {
+	speed := speedString(turn, rate)
+	fmt.Fprint(*conn, speed)
+	s.notifySpectators(speed)
 }
 
 func closeProgramm(turn int, done chan bool, ticker *ticker) {
-	ticker.ticker.Stop()
-	done <- true
+	ticker.stopTicker(done)
 }
 
+// ticker decides when AliveCellsCount and Throughput are reported: every period,
+// or, if every is set, after every that many turns.
 type ticker struct {
-	period time.Duration
-	ticker time.Ticker
+	mutex   sync.Mutex
+	period  time.Duration
+	every   int
+	ticker  *time.Ticker
+	stopped bool
 }
 
-func createTicker(period time.Duration) *ticker {
-	return &ticker{period, *time.NewTicker(period)}
+// defaultTickerPeriod is used when the controller does not choose a period.
+const defaultTickerPeriod = 2 * time.Second
+
+// tickerPeriods are the periods the 't' key cycles through.
+var tickerPeriods = []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second}
+
+func createTicker(period time.Duration, every int) *ticker {
+	if period <= 0 {
+		period = defaultTickerPeriod
+	}
+	return &ticker{period: period, every: every, ticker: time.NewTicker(period)}
 }
 
+// stopTicker stops the ticker and the goroutine reporting for it. Stopping a stopped ticker does nothing,
+// as its goroutine has already returned and nothing would receive from done, e.g. when a paused session
+// is stepped through its last turn.
 func (t *ticker) stopTicker(done chan bool) {
+	t.mutex.Lock()
+	if t.stopped {
+		t.mutex.Unlock()
+		return
+	}
 	t.ticker.Stop()
+	t.stopped = true
+	t.mutex.Unlock()
 	done <- true
 }
 
-func (t *ticker) resetTicker(conn *net.Conn, turn *int, world *[][]uint8, done chan bool) {
-	t.ticker = *time.NewTicker(t.period)
-	tickerRun(conn, turn, world, done, t)
+func (t *ticker) resetTicker(conn *net.Conn, s *session, done chan bool) {
+	t.mutex.Lock()
+	t.ticker = time.NewTicker(t.period)
+	t.stopped = false
+	t.mutex.Unlock()
+	tickerRun(conn, s, done, t)
+}
+
+// setPeriod changes how often the ticker fires, reporting every period from now on rather than every so many turns.
+// A stopped ticker uses the new period once it is reset.
+func (t *ticker) setPeriod(period time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.period = period
+	t.every = 0
+	if !t.stopped {
+		t.ticker.Reset(period)
+	}
+}
+
+// setEvery makes the ticker report after every that many turns instead of every period.
+func (t *ticker) setEvery(every int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.every = every
 }
 
-func sendAliveCellsCount(conn *net.Conn, turn int, world [][]uint8) {
-	finalString := sendAliveCellsCode + strconv.Itoa(turn) + "\n" + strconv.Itoa(len(getCurrentAliveCells(world))) + "\n"
+// nextPeriod switches to the next of tickerPeriods, reporting by time again if reports were every so many turns.
+func (t *ticker) nextPeriod() time.Duration {
+	period := tickerPeriods[0]
+	current := t.getPeriod()
+	for _, p := range tickerPeriods {
+		if p > current {
+			period = p
+			break
+		}
+	}
+	t.setPeriod(period)
+	return period
+}
+
+func (t *ticker) getPeriod() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.period
+}
+
+func (t *ticker) getEvery() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.every
+}
+
+// sendAliveCellsCount sends the number of alive cells in the latest world published by the session,
+// tagged with the turn that world belongs to.
+func sendAliveCellsCount(conn *net.Conn, s *session) {
+	latest := s.snapshot()
+	finalString := aliveCellsString(latest.turn, latest.alive)
 	fmt.Fprintf(*conn, finalString)
+	s.notifySpectators(finalString)
+}
+
+// sendThroughput reports how fast the session has been running since the last report.
+func sendThroughput(conn *net.Conn, s *session) {
+	throughputString := s.throughput.report(s.snapshot().turn)
+	fmt.Fprint(*conn, throughputString)
+	s.notifySpectators(throughputString)
 }
 
-func tickerRun(conn *net.Conn, turn *int, world *[][]uint8, done chan bool, ticker *ticker) {
+func aliveCellsString(turn int, alive int) string {
+	return sendAliveCellsCode + strconv.Itoa(turn) + "\n" + strconv.Itoa(alive) + "\n"
+}
+
+func tickerRun(conn *net.Conn, s *session, done chan bool, ticker *ticker) {
 	go func() {
 		for {
 			select {
 			case <-done:
 				return
 			case <-ticker.ticker.C:
-				sendAliveCellsCount(conn, *turn, *world)
+				// Reports every so many turns are sent by the distributor instead.
+				if ticker.getEvery() == 0 {
+					sendAliveCellsCount(conn, s)
+					sendThroughput(conn, s)
+				}
 			}
 		}
 	}()
 }
 
-func sendWritePgm(conn *net.Conn, turn int, world [][]byte) {
-	writePgmString := sendWritePgmCode + strconv.Itoa(turn) + "\n"
-
-	cells := getCurrentAliveCells(world)
+// cellsToString writes cells as the line of space separated coordinates the controller reads.
+func cellsToString(cells []Cell) string {
+	var builder strings.Builder
 	for _, cell := range cells {
-		writePgmString = writePgmString + strconv.Itoa(cell.X) + " " + strconv.Itoa(cell.Y) + " "
+		builder.WriteString(strconv.Itoa(cell.X) + " " + strconv.Itoa(cell.Y) + " ")
 	}
+	builder.WriteString("\n")
+	return builder.String()
+}
 
-	writePgmString = writePgmString + "\n"
+func sendWritePgm(conn *net.Conn, turn int, world [][]byte) {
+	writePgmString := sendWritePgmCode + strconv.Itoa(turn) + "\n" + cellsToString(getCurrentAliveCells(world))
 
-	fmt.Fprintf(*conn, writePgmString)
+	fmt.Fprint(*conn, writePgmString)
 }
 
 func sendFinalTurnComplete(conn *net.Conn, turn int, world [][]byte) {
-	finalTurnCompleteString := sendFinalTurnCompleteCode + strconv.Itoa(turn) + "\n"
-
-	cells := getCurrentAliveCells(world)
-	for _, cell := range cells {
-		finalTurnCompleteString = finalTurnCompleteString + strconv.Itoa(cell.X) + " " + strconv.Itoa(cell.Y) + " "
-	}
-
-	finalTurnCompleteString = finalTurnCompleteString + "\n"
+	finalTurnCompleteString := sendFinalTurnCompleteCode + strconv.Itoa(turn) + "\n" + cellsToString(getCurrentAliveCells(world))
 
-	fmt.Fprintf(*conn, finalTurnCompleteString)
+	fmt.Fprint(*conn, finalTurnCompleteString)
 }
 
-func sendTurnComplete(conn *net.Conn, turn int, world [][]byte) {
-	sendTurnCompleteString := sendTurnCompleteCode + strconv.Itoa(turn) + "\n"
+// sendTurnComplete tells the controller a turn has finished, listing only the cells that flipped during it
+// followed by the hash of the new world. The message is returned so that it can be passed on to spectators.
+func sendTurnComplete(conn *net.Conn, turn int, flipped []Cell, hash uint64) string {
+	sendTurnCompleteString := sendTurnCompleteCode + strconv.Itoa(turn) + "\n" + cellsToString(flipped) + strconv.FormatUint(hash, 16) + "\n"
 
-	cells := getCurrentAliveCells(world)
-	for _, cell := range cells {
-		sendTurnCompleteString = sendTurnCompleteString + strconv.Itoa(cell.X) + " " + strconv.Itoa(cell.Y) + " "
-	}
+	fmt.Fprint(*conn, sendTurnCompleteString)
+	return sendTurnCompleteString
+}
 
-	sendTurnCompleteString = sendTurnCompleteString + "\n"
+// sendWorld sends the whole world, for a controller that has to rebuild its copy of it.
+func sendWorld(conn *net.Conn, turn int, world [][]byte) {
+	fmt.Fprint(*conn, worldString(turn, world))
+}
 
-	fmt.Fprintf(*conn, sendTurnCompleteString)
+func worldString(turn int, world [][]byte) string {
+	return sendWorldCode + strconv.Itoa(turn) + "\n" + cellsToString(getCurrentAliveCells(world))
 }
 
 // distributor divides the work between workers and interacts with other goroutines.
-func distributor(p Params, world [][]byte, conn *net.Conn, keyPresses <-chan rune) {
+func distributor(p Params, world [][]byte, conn *net.Conn, s *session) {
 
 	turn := 0
+	// The rule was checked when the session was started.
+	r, _ := parseRule(p.Rule)
+	latest := s.snapshot()
 
-	ticker := createTicker(2 * time.Second)
+	ticker := s.ticker
 	done := make(chan bool)
 
-	tickerRun(conn, &turn, &world, done, ticker)
+	tickerRun(conn, s, done, ticker)
 
+	// Autosaves are sent in the background so that the computation never waits for them,
+	// but they must all arrive before the controller is told to close.
+	var autosaves sync.WaitGroup
+
+	// Once the world repeats it is stabilised, and with p.FinishWhenStable the session skips to its last turn
+	// when it reaches finishAt.
+	history := newHashHistory(p.HistoryDepth)
+	history.add(turn, latest.hash)
+	stabilised := false
+	finishAt := -1
+
+	rewinds := newRewindBuffer(p.RewindDepth)
+
+	action := keepRunning
 	for turn < p.Turns {
-		if manageSdlInput(p, conn, keyPresses, &turn, &world, done, ticker) {
+		// After a single step the session is still paused.
+		if action == stepTurn {
+			action = managePausedInput(conn, s, &turn, &world, done, &autosaves, rewinds)
+		} else {
+			action = manageSdlInput(p, conn, s, &turn, &world, done, &autosaves, rewinds)
+		}
+		if action == quitProgram {
 			return
 		}
+		if latest.turn != turn {
+			// The session was rewound. The turns after it are computed again, so the worlds seen since are forgotten.
+			latest = s.snapshot()
+			history = newHashHistory(p.HistoryDepth)
+			history.add(turn, latest.hash)
+		}
 
-		world = calculateDistributedStep(p, turn, world)
+		// Waiting for the limiter is done in short sleeps so that key presses are still answered promptly.
+		if action == keepRunning {
+			if wait := s.limiter.wait(); wait > 0 {
+				if wait > limiterPoll {
+					wait = limiterPoll
+				}
+				time.Sleep(wait)
+				continue
+			}
+		}
+
+		var changes stripChanges
+		var timings []workerTiming
+		world, changes, timings = calculateDistributedStep(p, r, turn, world)
 
 		turn++
+		latest = turnState{
+			turn:  turn,
+			world: world,
+			hash:  flipHash(latest.hash, changes.flipped),
+			alive: latest.alive + changes.births - changes.deaths,
+		}
 
-		//sendTurnComplete(conn, turn, world)
+		sent := time.Now()
+		turnComplete := sendTurnComplete(conn, turn, changes.flipped, latest.hash)
+		if p.CountEveryTurn {
+			aliveCells := aliveCellsString(turn, latest.alive)
+			fmt.Fprint(*conn, aliveCells)
+			turnComplete += aliveCells
+		}
+		s.update(latest, turnComplete)
+		s.throughput.addTurn(timings, time.Since(sent))
+		rewinds.add(changes)
+		s.checkpoints.add(turn, world)
+
+		period := history.add(turn, latest.hash)
+		if period > 0 && !stabilised {
+			stabilised = true
+			fmt.Printf("Stabilised with period %v from turn %v\n", period, turn-period)
+			s.setCycle(turn-period, period)
+			stabilisedString := sendStabilisedCode + strconv.Itoa(turn) + "\n" + strconv.Itoa(period) + "\n" + strconv.Itoa(turn-period) + "\n"
+			fmt.Fprint(*conn, stabilisedString)
+			s.notifySpectators(stabilisedString)
+			if p.FinishWhenStable {
+				// The last turn is the same as the turn of this cycle the same distance from its end.
+				finishAt = turn + (p.Turns-turn)%period
+			}
+		}
 
-		//Send information to the controller
-		// c.events <- TurnComplete{
-		// 	CompletedTurns: turn,
-		// }
+		// A breakpoint pauses the session before the next turn, unless it is already paused.
+		hits := s.checkBreakpoints(latest, period)
+		for _, hit := range hits {
+			fmt.Fprint(*conn, hit)
+			s.notifySpectators(hit)
+		}
+		if len(hits) > 0 && action != stepTurn {
+			s.setPauseAt(turn)
+		}
+
+		if every := ticker.getEvery(); every > 0 && turn%every == 0 {
+			sendAliveCellsCount(conn, s)
+			sendThroughput(conn, s)
+		}
+
+		if turn == finishAt && turn < p.Turns {
+			fmt.Println("Skipping to turn", p.Turns)
+			turn = p.Turns
+			latest.turn = turn
+			s.update(latest, sendTurnComplete(conn, turn, nil, latest.hash))
+		}
+
+		if p.AutosaveEvery > 0 && turn%p.AutosaveEvery == 0 && turn < p.Turns {
+			autosaves.Add(1)
+			// Every turn builds a new world, so this one is never modified again.
+			go func(turn int, world [][]byte) {
+				sendWritePgm(conn, turn, world)
+				autosaves.Done()
+			}(turn, world)
+		}
 	}
 
+	// The ticker is stopped first, as the controller stops listening once the final turn is complete.
+	closeProgramm(turn, done, ticker)
+
+	autosaves.Wait()
 	sendWritePgm(conn, turn, world)
 	sendFinalTurnComplete(conn, turn, world)
-
-	closeProgramm(turn, done, ticker)
 }
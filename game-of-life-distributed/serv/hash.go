@@ -0,0 +1,11 @@
+package serv
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// flipHash returns the hash of the world after the given cells flipped.
+func flipHash(hash uint64, flipped []Cell) uint64 {
+	for _, cell := range flipped {
+		hash ^= util.CellHash(cell.X, cell.Y)
+	}
+	return hash
+}
@@ -0,0 +1,45 @@
+package serv_test
+
+import (
+	"sync"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestHashes runs the same world with 1 to 16 threads and checks that the hash of the world is the same every turn.
+func TestHashes(t *testing.T) {
+	runServer()
+
+	const turns = 1000
+	const maxThreads = 16
+
+	hashes := make([][]uint64, maxThreads+1)
+	var wg sync.WaitGroup
+	for threads := 1; threads <= maxThreads; threads++ {
+		wg.Add(1)
+		go func(threads int) {
+			defer wg.Done()
+			p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: turns, Threads: threads}
+			runEvents(p, func(event gol.Event) {
+				switch e := event.(type) {
+				case gol.WorldHash:
+					hashes[threads] = append(hashes[threads], e.Hash)
+				}
+			})
+		}(threads)
+	}
+	wg.Wait()
+
+	for threads := 1; threads <= maxThreads; threads++ {
+		if len(hashes[threads]) != turns {
+			t.Fatalf("%v threads: expected %v hashes, got %v", threads, turns, len(hashes[threads]))
+		}
+		for turn, hash := range hashes[threads] {
+			if hash != hashes[1][turn] {
+				t.Errorf("%v threads: hash after turn %v is %016x, expected %016x", threads, turn+1, hash, hashes[1][turn])
+				break
+			}
+		}
+	}
+}
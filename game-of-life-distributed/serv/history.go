@@ -0,0 +1,47 @@
+package serv
+
+// hashHistory remembers the hashes of the worlds of the last few turns, to notice when the world repeats.
+type hashHistory struct {
+	turns  []int
+	hashes []uint64
+	seen   map[uint64]int
+	next   int
+}
+
+// defaultHistoryDepth is how many turns back a repeated world is looked for, which is the longest period found.
+const defaultHistoryDepth = 100
+
+func newHashHistory(depth int) *hashHistory {
+	if depth <= 0 {
+		depth = defaultHistoryDepth
+	}
+	return &hashHistory{
+		turns:  make([]int, 0, depth),
+		hashes: make([]uint64, 0, depth),
+		seen:   make(map[uint64]int, depth),
+	}
+}
+
+// add records the hash of the world after a turn. It returns how many turns ago the world was last the same,
+// or 0 if it has not been the same within the history.
+func (h *hashHistory) add(turn int, hash uint64) int {
+	period := 0
+	if previous, ok := h.seen[hash]; ok {
+		period = turn - previous
+	}
+
+	if len(h.hashes) < cap(h.hashes) {
+		h.turns = append(h.turns, turn)
+		h.hashes = append(h.hashes, hash)
+	} else {
+		// The oldest turn is forgotten, unless its world has been seen again since.
+		if h.seen[h.hashes[h.next]] == h.turns[h.next] {
+			delete(h.seen, h.hashes[h.next])
+		}
+		h.turns[h.next] = turn
+		h.hashes[h.next] = hash
+		h.next = (h.next + 1) % cap(h.hashes)
+	}
+	h.seen[hash] = turn
+	return period
+}
@@ -0,0 +1,95 @@
+package serv
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// limiter holds the distributor to a target number of turns per second. A rate of 0 runs as fast as possible.
+type limiter struct {
+	mutex sync.Mutex
+	rate  float64
+	next  time.Time
+}
+
+// limiterRates are the rates the '+' and '-' keys step through. Going faster than the last one removes the limit.
+var limiterRates = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// limiterPoll is the longest the distributor sleeps between checking for key presses while it waits for a turn.
+const limiterPoll = 20 * time.Millisecond
+
+func newLimiter(rate float64) *limiter {
+	if rate < 0 {
+		rate = 0
+	}
+	return &limiter{rate: rate}
+}
+
+// wait returns how long is left before the next turn may start, or 0 once it may start.
+// Starting a turn books the slot of the one after it, so turns never bunch up after a slow one or a pause.
+func (l *limiter) wait() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.rate == 0 {
+		return 0
+	}
+	now := time.Now()
+	if now.Before(l.next) {
+		return l.next.Sub(now)
+	}
+	l.next = now.Add(time.Duration(float64(time.Second) / l.rate))
+	return 0
+}
+
+// setRate changes the target rate. The next turn may start straight away.
+func (l *limiter) setRate(rate float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if rate < 0 {
+		rate = 0
+	}
+	l.rate = rate
+	l.next = time.Time{}
+}
+
+// faster moves to the next of limiterRates above the current rate, removing the limit after the last one.
+func (l *limiter) faster() float64 {
+	rate := 0.0
+	current := l.getRate()
+	if current != 0 {
+		for _, r := range limiterRates {
+			if r > current {
+				rate = r
+				break
+			}
+		}
+	}
+	l.setRate(rate)
+	return rate
+}
+
+// slower moves to the next of limiterRates below the current rate, stopping at the first one.
+func (l *limiter) slower() float64 {
+	rate := limiterRates[0]
+	current := l.getRate()
+	for _, r := range limiterRates {
+		if r < current || current == 0 {
+			rate = r
+		}
+	}
+	l.setRate(rate)
+	return rate
+}
+
+func (l *limiter) getRate() float64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.rate
+}
+
+// speedString tells the controller the target rate after it has been changed.
+func speedString(turn int, rate float64) string {
+	return sendSpeedCode + strconv.Itoa(turn) + "\n" + strconv.FormatFloat(rate, 'g', -1, 64) + "\n"
+}
@@ -0,0 +1,63 @@
+package serv_test
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestSpeed runs a session limited to 20 turns a second, pauses it and advances it 3 turns with 'n',
+// then speeds it up with '+' and checks that it is reported and that the turns were not run any faster.
+func TestSpeed(t *testing.T) {
+	runServer()
+
+	const turns = 30
+
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: turns, Threads: 4, TurnsPerSecond: 20}
+	keyPresses := make(chan rune, 10)
+
+	paused := false
+	pausedTurns := 0
+	var speeds []float64
+	start := time.Now()
+	runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 1 {
+				keyPresses <- 'p'
+			}
+			if paused {
+				pausedTurns++
+				if pausedTurns == 3 {
+					keyPresses <- '+'
+					keyPresses <- 'p'
+				}
+			}
+		case gol.StateChange:
+			switch e.NewState {
+			case gol.Paused:
+				paused = true
+				keyPresses <- 'n'
+				keyPresses <- 'n'
+				keyPresses <- 'n'
+			case gol.Executing:
+				paused = false
+			}
+		case gol.SpeedChange:
+			speeds = append(speeds, e.TurnsPerSecond)
+		}
+	})
+	elapsed := time.Since(start)
+
+	if pausedTurns != 3 {
+		t.Errorf("expected 3 turns while paused, got %v", pausedTurns)
+	}
+	if len(speeds) != 1 || speeds[0] != 50 {
+		t.Errorf("expected a single SpeedChange to 50 turns/s, got %v", speeds)
+	}
+	// At most 5 turns are run before the pause and the rest at 50 turns a second.
+	if minimum := (turns - 8) * time.Second / 50; elapsed < minimum {
+		t.Errorf("%v turns took %v, expected at least %v", turns, elapsed, minimum)
+	}
+}
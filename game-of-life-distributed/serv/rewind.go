@@ -0,0 +1,99 @@
+package serv
+
+import (
+	"fmt"
+	"net"
+)
+
+// rewindBuffer keeps the changes of the last few turns, so that a paused session can be stepped backwards.
+// Flipping the cells a turn flipped undoes it, so no worlds need to be kept.
+// The changes are kept in a ring of depth entries, where head is the oldest and count are in use.
+type rewindBuffer struct {
+	changes []stripChanges
+	head    int
+	count   int
+}
+
+// defaultRewindDepth is how many turns a session can be stepped back when the controller does not choose.
+const defaultRewindDepth = 100
+
+func newRewindBuffer(depth int) *rewindBuffer {
+	if depth <= 0 {
+		depth = defaultRewindDepth
+	}
+	return &rewindBuffer{changes: make([]stripChanges, depth)}
+}
+
+// add records the changes of a turn, overwriting the oldest turn once the buffer is full.
+func (b *rewindBuffer) add(changes stripChanges) {
+	b.changes[(b.head+b.count)%len(b.changes)] = changes
+	if b.count == len(b.changes) {
+		b.head = (b.head + 1) % len(b.changes)
+	} else {
+		b.count++
+	}
+}
+
+// pop removes the changes of the latest turn, releasing them.
+func (b *rewindBuffer) pop() stripChanges {
+	latest := (b.head + b.count - 1) % len(b.changes)
+	changes := b.changes[latest]
+	b.changes[latest] = stripChanges{}
+	b.count--
+	return changes
+}
+
+// rewind undoes up to the given number of turns, returning the state of the earlier turn
+// together with the cells that differ from the world it was given. The world it was given is not modified.
+func (b *rewindBuffer) rewind(latest turnState, turns int) (turnState, []Cell) {
+	world := make([][]byte, len(latest.world))
+	for x := range world {
+		world[x] = append([]byte(nil), latest.world[x]...)
+	}
+
+	var undone []Cell
+	for i := 0; i < turns && b.count > 0; i++ {
+		changes := b.pop()
+
+		for _, cell := range changes.flipped {
+			world[cell.X][cell.Y] = alive - world[cell.X][cell.Y]
+		}
+		undone = append(undone, changes.flipped...)
+		latest.hash = flipHash(latest.hash, changes.flipped)
+		latest.alive += changes.deaths - changes.births
+		latest.turn--
+	}
+
+	// A cell flipped by several of the turns is only sent if it ended up different.
+	var flipped []Cell
+	sent := make(map[Cell]bool)
+	for _, cell := range undone {
+		if world[cell.X][cell.Y] != latest.world[cell.X][cell.Y] && !sent[cell] {
+			sent[cell] = true
+			flipped = append(flipped, cell)
+		}
+	}
+
+	latest.world = world
+	return latest, flipped
+}
+
+// rewindProgram steps a paused session back by a number of turns and sends the controller the cells that changed,
+// as it would for a completed turn. Resuming the session computes the turns after it again.
+func rewindProgram(conn *net.Conn, s *session, rewinds *rewindBuffer, turns int, turn *int, world *[][]uint8) {
+	if turns <= 0 {
+		return
+	}
+	latest, flipped := rewinds.rewind(s.snapshot(), turns)
+	if latest.turn == *turn {
+		fmt.Println("Cannot rewind further than turn", *turn)
+		return
+	}
+	if latest.turn > *turn-turns {
+		fmt.Println("Can only rewind to turn", latest.turn)
+	}
+	*turn = latest.turn
+	*world = latest.world
+	s.update(latest, sendTurnComplete(conn, latest.turn, flipped, latest.hash))
+	fmt.Println("Rewound to turn", latest.turn)
+}
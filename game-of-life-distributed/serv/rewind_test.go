@@ -0,0 +1,112 @@
+package serv_test
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestRewind pauses a session, steps it back a turn with 'u' and 5 more with 'j', then resumes it.
+// Every turn it goes back to must have the hash it had the first time, and the session must end
+// with the same world as one that was never rewound.
+func TestRewind(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 1000, Threads: 4}
+	expected := runSession(p)
+
+	keyPresses := make(chan rune, 10)
+	hashes := make(map[int]uint64)
+	paused := -1
+	resumed := false
+	var rewound []int
+	var final []util.Cell
+	runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 30 && paused < 0 {
+				keyPresses <- 'p'
+			}
+		case gol.WorldHash:
+			if hash, ok := hashes[e.CompletedTurns]; ok && hash != e.Hash {
+				t.Errorf("hash after turn %v is %016x, expected %016x", e.CompletedTurns, e.Hash, hash)
+			}
+			hashes[e.CompletedTurns] = e.Hash
+			if paused >= 0 && !resumed && e.CompletedTurns < paused {
+				rewound = append(rewound, e.CompletedTurns)
+				if len(rewound) == 1 {
+					for _, key := range "j5\n" {
+						keyPresses <- key
+					}
+				} else {
+					keyPresses <- 'p'
+				}
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				paused = e.CompletedTurns
+				keyPresses <- 'u'
+			} else if e.NewState == gol.Executing {
+				resumed = true
+			}
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	})
+
+	if len(rewound) != 2 || rewound[1] != rewound[0]-5 {
+		t.Errorf("expected to rewind a turn and then 5 more, rewound to %v", rewound)
+	}
+	if !sameCells(final, expected) {
+		t.Errorf("final world differs from a session that was never rewound:\n%v", util.AliveCellsToString(final, expected, p.ImageWidth, p.ImageHeight))
+	}
+}
+
+// TestRewindDepth pauses a session that keeps only 3 turns to rewind, long after they have wrapped around,
+// and checks that jumping back 10 turns only goes back 3, to the world it had the first time.
+func TestRewindDepth(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 1000, Threads: 4, RewindDepth: 3}
+	expected := runSession(p)
+
+	keyPresses := make(chan rune, 10)
+	hashes := make(map[int]uint64)
+	paused := -1
+	var rewound []int
+	var final []util.Cell
+	runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 30 && paused < 0 {
+				keyPresses <- 'p'
+			}
+		case gol.WorldHash:
+			if hash, ok := hashes[e.CompletedTurns]; ok && hash != e.Hash {
+				t.Errorf("hash after turn %v is %016x, expected %016x", e.CompletedTurns, e.Hash, hash)
+			}
+			hashes[e.CompletedTurns] = e.Hash
+			if paused >= 0 && len(rewound) == 0 && e.CompletedTurns < paused {
+				rewound = append(rewound, e.CompletedTurns)
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused && paused < 0 {
+				paused = e.CompletedTurns
+				for _, key := range "j10\n" {
+					keyPresses <- key
+				}
+			}
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	})
+
+	if len(rewound) != 1 || rewound[0] != paused-3 {
+		t.Errorf("expected to rewind from turn %v to %v, rewound to %v", paused, paused-3, rewound)
+	}
+	if !sameCells(final, expected) {
+		t.Errorf("final world differs from a session that was never rewound:\n%v", util.AliveCellsToString(final, expected, p.ImageWidth, p.ImageHeight))
+	}
+}
@@ -0,0 +1,29 @@
+package serv
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// rule says, for every number of alive neighbours, whether a dead cell is born and whether an alive cell survives.
+type rule struct {
+	birth   [9]bool
+	survive [9]bool
+}
+
+// parseRule reads a rule in B/S or S/B notation, as described by util.ParseRule.
+func parseRule(ruleString string) (rule, error) {
+	birth, survive, err := util.ParseRule(ruleString)
+	return rule{birth: birth, survive: survive}, err
+}
+
+// next returns the state of a cell in the next turn.
+func (r rule) next(cell uint8, neighbours int) uint8 {
+	if cell == alive {
+		if r.survive[neighbours] {
+			return alive
+		}
+		return dead
+	}
+	if r.birth[neighbours] {
+		return alive
+	}
+	return dead
+}
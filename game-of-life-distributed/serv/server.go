@@ -2,22 +2,51 @@ package serv
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var server net.Listener
 
+// MaxSessions is the number of sessions the server runs at once. Further controllers are turned away.
+// It is set by the -maxSessions flag of the server and must be set before RunServ is called.
+var MaxSessions = 16
+
 //Params is tmp
 type Params struct {
 	Turns       int
 	Threads     int
 	ImageWidth  int
 	ImageHeight int
+	Rule        string
+	// AutosaveEvery is how many turns apart the world is sent to the controller to be saved. 0 disables autosaving.
+	AutosaveEvery int
+	SessionID     string
+	// CountEveryTurn sends the number of alive cells after every turn, as well as every time the ticker fires.
+	CountEveryTurn bool
+	// TickerPeriod is how often AliveCellsCount is sent. 0 uses the default of 2 seconds.
+	TickerPeriod time.Duration
+	// TickerEvery sends AliveCellsCount after every that many turns instead of every TickerPeriod. 0 disables it.
+	TickerEvery int
+	// TurnsPerSecond is the most turns the session runs a second. 0 runs it as fast as possible.
+	TurnsPerSecond float64
+	// Breakpoints pause the session when their condition becomes true. Their format is described by breakpoint.
+	Breakpoints []string
+	// HistoryDepth is how many turns back the world is looked for to notice that it repeats. 0 uses the default of 100.
+	HistoryDepth int
+	// FinishWhenStable skips to the last turn once the world repeats, as it is known from then on.
+	FinishWhenStable bool
+	// RewindDepth is how many turns a paused session can be stepped back. 0 uses the default of 100.
+	RewindDepth int
+	// CheckpointEvery is how many turns apart the worlds kept to compute past turns again start out. 0 uses the default of 100.
+	CheckpointEvery int
 }
 
 //DataToSend is data to send
@@ -40,9 +69,9 @@ func logerr(err error) bool {
 }
 
 func createWorldAliveCells(p Params, aliveCells []Cell) [][]byte {
-	initialWorld := make([][]byte, p.ImageHeight)
+	initialWorld := make([][]byte, p.ImageWidth)
 	for i := range initialWorld {
-		initialWorld[i] = make([]byte, p.ImageWidth)
+		initialWorld[i] = make([]byte, p.ImageHeight)
 	}
 
 	for _, x := range aliveCells {
@@ -52,46 +81,136 @@ func createWorldAliveCells(p Params, aliveCells []Cell) [][]byte {
 	return initialWorld
 }
 
-func read(conn *net.Conn) (Params, [][]byte) {
-	reader := bufio.NewReader(*conn)
-	//fmt.Println("Am intrat in reader!!!")
+// breakpointField names the fields of the parameters line that hold a breakpoint each.
+const breakpointField = "breakpoint="
 
-	for {
-		paramsString, _ := reader.ReadString('\n')
-		if len(paramsString) > 0 {
-			//fmt.Println("Astia sunt parametrii: ", paramsString)
-			aliveCellsString, _ := reader.ReadString('\n')
+// fieldParser parses the fields of a parameters line, keeping the first field it fails to parse.
+type fieldParser struct {
+	err error
+}
 
-			p := strings.Fields(paramsString)
+func (f *fieldParser) check(field string, err error) {
+	if err != nil && f.err == nil {
+		f.err = fmt.Errorf("invalid parameter %q", field)
+	}
+}
+
+func (f *fieldParser) int(field string) int {
+	value, err := strconv.Atoi(field)
+	f.check(field, err)
+	return value
+}
 
-			params := Params{}
-			params.ImageHeight, _ = strconv.Atoi(p[0])
-			params.ImageWidth, _ = strconv.Atoi(p[1])
-			params.Threads, _ = strconv.Atoi(p[2])
-			params.Turns, _ = strconv.Atoi(p[3])
+func (f *fieldParser) bool(field string) bool {
+	value, err := strconv.ParseBool(field)
+	f.check(field, err)
+	return value
+}
 
-			//fmt.Println("Celule vii: ", aliveCellsString)
+func (f *fieldParser) float(field string) float64 {
+	value, err := strconv.ParseFloat(field, 64)
+	f.check(field, err)
+	return value
+}
 
-			aliveCellsArray := strings.Fields(aliveCellsString)
+func (f *fieldParser) duration(field string) time.Duration {
+	value, err := time.ParseDuration(field)
+	f.check(field, err)
+	return value
+}
 
-			var aliveCells []Cell
+// read parses the parameters and initial alive cells a controller sends when it starts a session.
+// It returns an error if the lines are malformed, rather than trusting a controller to send valid ones.
+func read(paramsString string, reader *bufio.Reader) (Params, [][]byte, error) {
+	aliveCellsString, _ := reader.ReadString('\n')
 
-			for i := 0; i < len(aliveCellsArray); i = i + 2 {
-				cell := Cell{}
-				cell.X, _ = strconv.Atoi(aliveCellsArray[i])
-				cell.Y, _ = strconv.Atoi(aliveCellsArray[i+1])
-				aliveCells = append(aliveCells, cell)
-			}
+	// Breakpoints are named fields after the positional ones, as in "breakpoint=alive<100", one for each.
+	params := Params{}
+	var p []string
+	for _, field := range strings.Fields(paramsString) {
+		if strings.HasPrefix(field, breakpointField) {
+			params.Breakpoints = append(params.Breakpoints, strings.TrimPrefix(field, breakpointField))
+		} else {
+			p = append(p, field)
+		}
+	}
+	if len(p) < 4 {
+		return params, nil, fmt.Errorf("expected at least 4 parameters, got %v", len(p))
+	}
 
-			world := createWorldAliveCells(params, aliveCells)
+	f := fieldParser{}
+	params.ImageHeight = f.int(p[0])
+	params.ImageWidth = f.int(p[1])
+	params.Threads = f.int(p[2])
+	params.Turns = f.int(p[3])
+	if len(p) > 4 {
+		params.Rule = p[4]
+	}
+	if len(p) > 5 {
+		params.AutosaveEvery = f.int(p[5])
+	}
+	if len(p) > 6 {
+		params.SessionID = p[6]
+	}
+	if len(p) > 7 {
+		params.CountEveryTurn = f.bool(p[7])
+	}
+	if len(p) > 9 {
+		params.TickerPeriod = f.duration(p[8])
+		params.TickerEvery = f.int(p[9])
+	}
+	if len(p) > 10 {
+		params.TurnsPerSecond = f.float(p[10])
+	}
+	if len(p) > 12 {
+		params.HistoryDepth = f.int(p[11])
+		params.FinishWhenStable = f.bool(p[12])
+	}
+	if len(p) > 13 {
+		params.RewindDepth = f.int(p[13])
+	}
+	if len(p) > 14 {
+		params.CheckpointEvery = f.int(p[14])
+	}
+	if f.err != nil {
+		return params, nil, f.err
+	}
+	if params.ImageWidth < 1 || params.ImageHeight < 1 {
+		return params, nil, fmt.Errorf("invalid size %vx%v", params.ImageWidth, params.ImageHeight)
+	}
+	if params.Threads < 1 || params.Threads > params.ImageWidth {
+		return params, nil, fmt.Errorf("invalid number of threads %v for a width of %v", params.Threads, params.ImageWidth)
+	}
 
-			return params, world
+	aliveCellsArray := strings.Fields(aliveCellsString)
+	if len(aliveCellsArray)%2 != 0 {
+		return params, nil, errors.New("expected the alive cells as x y pairs")
+	}
+
+	var aliveCells []Cell
+
+	for i := 0; i < len(aliveCellsArray); i = i + 2 {
+		cell := Cell{}
+		cell.X = f.int(aliveCellsArray[i])
+		cell.Y = f.int(aliveCellsArray[i+1])
+		if f.err != nil {
+			return params, nil, f.err
+		}
+		if cell.X < 0 || cell.X >= params.ImageWidth || cell.Y < 0 || cell.Y >= params.ImageHeight {
+			return params, nil, fmt.Errorf("alive cell %v,%v is outside the world", cell.X, cell.Y)
 		}
+		aliveCells = append(aliveCells, cell)
 	}
+
+	world := createWorldAliveCells(params, aliveCells)
+
+	return params, world, nil
 }
 
-func receiverSDL(conn *net.Conn, keyPresses chan<- rune) {
-	reader := bufio.NewReader(*conn)
+// receiverSDL passes the keys sent by the controller on to the session.
+// 'a' is followed by the number of turns to run before pausing, as in "a 500", 'j' by the number of turns
+// to rewind, 'w' by a past turn whose world to send and 'b' by a breakpoint to add, as in "b alive<100".
+func receiverSDL(conn *net.Conn, reader *bufio.Reader, s *session) {
 	for {
 		codeChar, err := reader.ReadString('\n')
 		if err != nil {
@@ -99,12 +218,50 @@ func receiverSDL(conn *net.Conn, keyPresses chan<- rune) {
 			return
 		}
 		if len(codeChar) > 0 {
-			keyPresses <- rune(codeChar[0])
+			fields := strings.Fields(codeChar)
+			if len(fields) == 2 && (fields[0] == "a" || fields[0] == "j") {
+				argument, _ := strconv.Atoi(fields[1])
+				s.setArgument(argument)
+			}
+			// Past worlds are computed in the background, so that the session carries on meanwhile.
+			if len(fields) == 2 && fields[0] == "w" {
+				turn, _ := strconv.Atoi(fields[1])
+				go func() {
+					world, err := s.worldAt(turn)
+					sendWorldAt(conn, turn, world, err)
+				}()
+				continue
+			}
+			// Breakpoints are checked by the distributor after every turn, so it need not be told about them.
+			if len(fields) == 2 && fields[0] == "b" {
+				err := s.addBreakpoint(fields[1])
+				if err != nil {
+					fmt.Println(err)
+				}
+				continue
+			}
+			s.keyPresses <- rune(codeChar[0])
 		}
 	}
 }
 
-func handle(conn *net.Conn) {
+// lockedConn is a connection to a controller that many goroutines write to: the distributor, the ticker, autosaves
+// and past worlds. Every message is written with a single Write, which holds the lock until all of it has been sent,
+// so messages are never interleaved however large they are.
+type lockedConn struct {
+	net.Conn
+	mutex sync.Mutex
+}
+
+func (c *lockedConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.Conn.Write(b)
+}
+
+func handle(accepted *net.Conn) {
+	var locked net.Conn = &lockedConn{Conn: *accepted}
+	conn := &locked
 	//timeoutDuration := 5 * time.Second
 	fmt.Println("Launching server...")
 	//(*conn).SetReadDeadline(time.Now().Add(timeoutDuration))
@@ -112,20 +269,77 @@ func handle(conn *net.Conn) {
 	remoteAddr := (*conn).RemoteAddr().String()
 	fmt.Println("Client connected from " + remoteAddr)
 
-	p, w := read(conn)
+	// The first line either starts a session, or asks to watch or describe a running one.
+	reader := bufio.NewReader(*conn)
+	firstLine := ""
+	for len(strings.TrimSpace(firstLine)) == 0 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			(*conn).Close()
+			return
+		}
+		firstLine = line
+	}
+	fields := strings.Fields(firstLine)
+	if len(fields) == 2 && fields[0] == "spectate" {
+		spectate(conn, reader, fields[1])
+		return
+	}
+	if len(fields) == 2 && fields[0] == "describe" {
+		describe(conn, fields[1])
+		(*conn).Close()
+		return
+	}
 
-	keyPresses := make(chan rune, 10)
+	p, w, err := read(firstLine, reader)
+	if err == nil {
+		_, err = parseRule(p.Rule)
+	}
+	if err != nil {
+		sendRejected(conn, err)
+		(*conn).Close()
+		return
+	}
+	if p.SessionID == "" {
+		p.SessionID = remoteAddr
+	}
 
-	go receiverSDL(conn, keyPresses)
+	s := newSession(p.SessionID, p, w)
+	s.conn = conn
+	err = addSession(s)
+	if err != nil {
+		// The session never runs, so nothing else would stop its ticker.
+		s.ticker.ticker.Stop()
+		sendRejected(conn, err)
+		(*conn).Close()
+		return
+	}
 
-	distributor(p, w, conn, keyPresses)
+	for _, spec := range p.Breakpoints {
+		err = s.addBreakpoint(spec)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	go receiverSDL(conn, reader, s)
+
+	distributor(p, w, conn, s)
+	removeSession(s)
+	s.closeSpectators(s.status().Turn)
 
 	(*conn).Close()
-	server.Close()
 	//resp(conn, d)
 }
 
-//RunServ runs the server
+// sendRejected tells a controller that its session could not be started.
+func sendRejected(conn *net.Conn, err error) {
+	rejectedString := sendRejectedCode + err.Error() + "\n"
+	fmt.Fprint(*conn, rejectedString)
+}
+
+// RunServ runs the server. Every controller that connects gets its own session, with its own distributor,
+// ticker and world, until MaxSessions are running.
 func RunServ() {
 	server, _ = net.Listen("tcp", ":8030")
 	//fmt.Println("I'm listening!")
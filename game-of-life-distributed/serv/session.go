@@ -0,0 +1,266 @@
+package serv
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// session is a simulation run by the server for a controller.
+// The distributor publishes its progress here after every turn so that it can be inspected from other goroutines.
+type session struct {
+	id         string
+	p          Params
+	keyPresses chan rune
+	ticker     *ticker
+	throughput *throughput
+	limiter    *limiter
+	// checkpoints are the past worlds the world of any past turn is computed again from.
+	checkpoints *checkpoints
+	// conn is the connection to the controller, for replies that do not come from the distributor.
+	conn *net.Conn
+
+	mutex      sync.Mutex
+	latest     turnState
+	state      string
+	spectators []*spectator
+	// argument is the number sent with the latest key that takes one, such as 'a', until the distributor takes it.
+	argument int
+	// pauseAt is the turn the session pauses at, or 0 if it runs on.
+	pauseAt     int
+	breakpoints []*breakpoint
+	// cycleFrom and cyclePeriod describe the cycle the world repeats once it is stabilised. cyclePeriod is 0 until then.
+	cycleFrom   int
+	cyclePeriod int
+}
+
+// turnState is the world after a turn together with what is known about it.
+// It is only ever published as a whole, so that observers never mix up the world of one turn with the count of another.
+type turnState struct {
+	turn  int
+	world [][]byte
+	hash  uint64
+	alive int
+}
+
+// sessionStatus is a snapshot of a session, as returned by the control API.
+type sessionStatus struct {
+	ID           string `json:"id"`
+	Turn         int    `json:"turn"`
+	AliveCells   int    `json:"aliveCells"`
+	Hash         string `json:"hash"`
+	State        string `json:"state"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Threads      int    `json:"threads"`
+	Turns        int    `json:"turns"`
+	TickerPeriod string `json:"tickerPeriod"`
+	TickerEvery  int    `json:"tickerEvery"`
+	// PauseAt is the turn the session will pause at, or 0 if it runs on.
+	PauseAt     int      `json:"pauseAt"`
+	Breakpoints []string `json:"breakpoints"`
+	// TurnsPerSecond is the target rate of the session. 0 means it runs as fast as it can.
+	TurnsPerSecond float64 `json:"turnsPerSecond"`
+}
+
+var sessionsMutex sync.Mutex
+var sessions = make(map[string]*session)
+
+func newSession(id string, p Params, world [][]byte) *session {
+	return &session{
+		id:          id,
+		p:           p,
+		keyPresses:  make(chan rune, 10),
+		ticker:      createTicker(p.TickerPeriod, p.TickerEvery),
+		throughput:  newThroughput(p.Threads),
+		limiter:     newLimiter(p.TurnsPerSecond),
+		checkpoints: newCheckpoints(p.CheckpointEvery, world),
+		latest:      newTurnState(world),
+		state:       "Executing",
+	}
+}
+
+// addSession registers a session, failing if its id is taken or MaxSessions are already running.
+func addSession(s *session) error {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	if _, ok := sessions[s.id]; ok {
+		return fmt.Errorf("session %v already exists", s.id)
+	}
+	if len(sessions) >= MaxSessions {
+		return fmt.Errorf("the server is already running %v sessions", MaxSessions)
+	}
+	sessions[s.id] = s
+	return nil
+}
+
+func removeSession(s *session) {
+	sessionsMutex.Lock()
+	delete(sessions, s.id)
+	sessionsMutex.Unlock()
+}
+
+func getSession(id string) *session {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	return sessions[id]
+}
+
+// newTurnState works out the hash and alive cells of a world from scratch. After that they are maintained from
+// the changes of every turn.
+func newTurnState(world [][]byte) turnState {
+	return turnState{
+		world: world,
+		hash:  util.WorldHash(world),
+		alive: len(getCurrentAliveCells(world)),
+	}
+}
+
+// update publishes the world after a completed turn and passes its diff on to the spectators.
+// Worlds are never modified once a turn is complete.
+func (s *session) update(latest turnState, turnComplete string) {
+	s.mutex.Lock()
+	s.latest = latest
+	s.sendSpectators(turnComplete)
+	s.mutex.Unlock()
+}
+
+// setCycle records that the world repeats every period turns from turn from.
+func (s *session) setCycle(from, period int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cycleFrom, s.cyclePeriod = from, period
+}
+
+// cycle returns the first turn and period of the cycle the world repeats, or a period of 0 if it has not stabilised.
+func (s *session) cycle() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.cycleFrom, s.cyclePeriod
+}
+
+// snapshot returns the latest completed turn. The world must not be modified.
+func (s *session) snapshot() turnState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.latest
+}
+
+// notifySpectators passes a message that is not part of a turn, such as an alive cells count, on to the spectators.
+func (s *session) notifySpectators(message string) {
+	s.mutex.Lock()
+	s.sendSpectators(message)
+	s.mutex.Unlock()
+}
+
+func (s *session) setState(state string) {
+	s.mutex.Lock()
+	s.state = state
+	s.mutex.Unlock()
+}
+
+// setArgument stores the number sent with a key, such as the number of turns to run before pausing with 'a'.
+// It is acted on when the session receives the key.
+func (s *session) setArgument(argument int) {
+	s.mutex.Lock()
+	s.argument = argument
+	s.mutex.Unlock()
+}
+
+// takeArgument returns the number stored by setArgument, so that each is only acted on once.
+func (s *session) takeArgument() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	argument := s.argument
+	s.argument = 0
+	return argument
+}
+
+func (s *session) setPauseAt(turn int) {
+	s.mutex.Lock()
+	s.pauseAt = turn
+	s.mutex.Unlock()
+}
+
+func (s *session) getPauseAt() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.pauseAt
+}
+
+// addBreakpoint adds a breakpoint written as described by breakpoint, or removes them all if spec is "clear".
+// The condition is checked against the latest world straight away, so that it is only hit once it changes.
+func (s *session) addBreakpoint(spec string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if spec == "clear" {
+		s.breakpoints = nil
+		return nil
+	}
+	b, err := parseBreakpoint(spec)
+	if err != nil {
+		return err
+	}
+	b.check(s.latest, 0)
+	s.breakpoints = append(s.breakpoints, b)
+	return nil
+}
+
+// checkBreakpoints checks every breakpoint against the world after a turn and returns the messages
+// telling the controller which were hit.
+func (s *session) checkBreakpoints(latest turnState, period int) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var hits []string
+	for _, b := range s.breakpoints {
+		if hit, reason := b.check(latest, period); hit {
+			fmt.Printf("Breakpoint %v hit at turn %v: %v\n", b.spec, latest.turn, reason)
+			hits = append(hits, breakpointHitString(latest.turn, b.spec, reason))
+		}
+	}
+	return hits
+}
+
+func (s *session) status() sessionStatus {
+	s.mutex.Lock()
+	latest, state, pauseAt := s.latest, s.state, s.pauseAt
+	breakpoints := []string{}
+	for _, b := range s.breakpoints {
+		breakpoints = append(breakpoints, b.spec)
+	}
+	s.mutex.Unlock()
+
+	return sessionStatus{
+		ID:           s.id,
+		Turn:         latest.turn,
+		AliveCells:   latest.alive,
+		Hash:         fmt.Sprintf("%016x", latest.hash),
+		State:        state,
+		Width:        s.p.ImageWidth,
+		Height:       s.p.ImageHeight,
+		Threads:      s.p.Threads,
+		Turns:        s.p.Turns,
+		TickerPeriod: s.ticker.getPeriod().String(),
+		TickerEvery:  s.ticker.getEvery(),
+		PauseAt:      pauseAt,
+		Breakpoints:  breakpoints,
+
+		TurnsPerSecond: s.limiter.getRate(),
+	}
+}
+
+// press sends a key to the session as if the controller had sent it.
+// It returns false if the session is not accepting keys.
+func (s *session) press(key rune) bool {
+	select {
+	case s.keyPresses <- key:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,223 @@
+package serv_test
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/serv"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var startServer sync.Once
+
+// runServer starts the server the first time a test needs it.
+func runServer() {
+	startServer.Do(func() {
+		serv.RunServ()
+		time.Sleep(100 * time.Millisecond)
+	})
+}
+
+// runEvents runs a whole game against the server, passing every event to handle.
+func runEvents(p gol.Params, handle func(event gol.Event)) {
+	runEventsWithKeys(p, nil, handle)
+}
+
+// runEventsWithKeys runs a whole game against the server like runEvents, pressing the keys sent on keyPresses.
+func runEventsWithKeys(p gol.Params, keyPresses <-chan rune, handle func(event gol.Event)) {
+	outputDir, err := ioutil.TempDir("", "gol-session")
+	util.Check(err)
+	defer os.RemoveAll(outputDir)
+
+	p.InputFile = fmt.Sprintf("../images/%vx%v.pgm", p.ImageWidth, p.ImageHeight)
+	p.OutputDir = outputDir
+
+	events := make(chan gol.Event)
+	gol.Run(p, events, keyPresses)
+	for event := range events {
+		handle(event)
+	}
+}
+
+// runSession runs a whole game against the server, returning the final alive cells.
+func runSession(p gol.Params) []util.Cell {
+	var cells []util.Cell
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	})
+	return cells
+}
+
+func sameCells(given, expected []util.Cell) bool {
+	if len(given) != len(expected) {
+		return false
+	}
+	alive := make(map[util.Cell]bool)
+	for _, cell := range expected {
+		alive[cell] = true
+	}
+	for _, cell := range given {
+		if !alive[cell] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSessions runs 8 sessions on one server at the same time and checks each against the expected images.
+func TestSessions(t *testing.T) {
+	runServer()
+
+	tests := []gol.Params{
+		{ImageWidth: 16, ImageHeight: 16, Turns: 0, Threads: 1},
+		{ImageWidth: 16, ImageHeight: 16, Turns: 1, Threads: 2},
+		{ImageWidth: 16, ImageHeight: 16, Turns: 100, Threads: 4},
+		{ImageWidth: 64, ImageHeight: 64, Turns: 0, Threads: 3},
+		{ImageWidth: 64, ImageHeight: 64, Turns: 1, Threads: 8},
+		{ImageWidth: 64, ImageHeight: 64, Turns: 100, Threads: 16},
+		{ImageWidth: 512, ImageHeight: 512, Turns: 1, Threads: 5},
+		{ImageWidth: 512, ImageHeight: 512, Turns: 100, Threads: 8},
+	}
+
+	// Every session is started before any is checked, so that all 8 run at the same time.
+	results := make([][]util.Cell, len(tests))
+	var wg sync.WaitGroup
+	for i, p := range tests {
+		wg.Add(1)
+		go func(i int, p gol.Params) {
+			defer wg.Done()
+			results[i] = runSession(p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for i, p := range tests {
+		testName := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+		expected := util.ReadAliveCells(
+			fmt.Sprintf("../check/images/%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, p.Turns),
+			p.ImageWidth,
+			p.ImageHeight,
+		)
+		if !sameCells(results[i], expected) {
+			t.Errorf("%v: expected %v alive cells, got %v different ones", testName, len(expected), len(results[i]))
+		}
+	}
+}
+
+// TestMaxSessions fills the server with sessions and checks that one more is rejected without running any turns.
+func TestMaxSessions(t *testing.T) {
+	runServer()
+
+	maxSessions := serv.MaxSessions
+	serv.MaxSessions = 2
+	defer func() { serv.MaxSessions = maxSessions }()
+
+	// The running sessions are held to a slow rate, so that they are still running when the last one connects.
+	running := make([]chan rune, serv.MaxSessions)
+	var started, finished sync.WaitGroup
+	for i := range running {
+		running[i] = make(chan rune, 10)
+		started.Add(1)
+		finished.Add(1)
+		go func(keyPresses chan rune) {
+			defer finished.Done()
+			first := true
+			p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100000, Threads: 1, TurnsPerSecond: 10}
+			runEventsWithKeys(p, keyPresses, func(event gol.Event) {
+				if _, ok := event.(gol.TurnComplete); ok && first {
+					first = false
+					started.Done()
+				}
+			})
+		}(running[i])
+	}
+	started.Wait()
+
+	var rejected *gol.Rejected
+	turns := 0
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 10, Threads: 1}
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.Rejected:
+			rejected = &e
+		case gol.TurnComplete:
+			turns++
+		}
+	})
+
+	for _, keyPresses := range running {
+		keyPresses <- 'q'
+	}
+	finished.Wait()
+
+	if rejected == nil {
+		t.Fatalf("expected the session after %v running ones to be rejected", serv.MaxSessions)
+	}
+	if turns != 0 {
+		t.Errorf("expected a rejected session to run no turns, got %v", turns)
+	}
+}
+
+// startRaw sends a session's parameters line and alive cells straight to the server, as a controller would,
+// and returns the first message the server answers with.
+func startRaw(t *testing.T, paramsLine string) string {
+	conn, err := net.Dial("tcp", gol.DefaultServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprint(conn, paramsLine+"\n\n")
+	reader := bufio.NewReader(conn)
+	code, _ := reader.ReadString('\n')
+	message, _ := reader.ReadString('\n')
+	return strings.TrimSpace(code) + " " + strings.TrimSpace(message)
+}
+
+// TestInvalidRule checks that the server rejects a session whose rule cannot be parsed.
+func TestInvalidRule(t *testing.T) {
+	runServer()
+
+	for _, rule := range []string{"B3/S2x3", "foo", "B3/S23/B1"} {
+		answer := startRaw(t, "16 16 1 10 "+rule)
+		if !strings.HasPrefix(answer, "8 invalid rule") {
+			t.Errorf("%v: expected the session to be rejected, got %q", rule, answer)
+		}
+	}
+}
+
+// TestMalformedParams checks that the server rejects sessions whose parameters or alive cells cannot be parsed,
+// rather than panicking.
+func TestMalformedParams(t *testing.T) {
+	runServer()
+
+	tests := []struct {
+		paramsLine string
+		expected   string
+	}{
+		{"16 16", "8 expected at least 4 parameters"},
+		{"16 x 1 10", "8 invalid parameter \"x\""},
+		{"16 16 1 10 B3/S23 0 malformed maybe", "8 invalid parameter \"maybe\""},
+		{"0 16 1 10", "8 invalid size"},
+		{"16 16 0 10", "8 invalid number of threads"},
+		{"16 16 1 10\n1 2 3", "8 expected the alive cells as x y pairs"},
+		{"16 16 1 10\n1 16", "8 alive cell 1,16 is outside the world"},
+	}
+	for _, test := range tests {
+		answer := startRaw(t, test.paramsLine)
+		if !strings.HasPrefix(answer, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.paramsLine, test.expected, answer)
+		}
+	}
+}
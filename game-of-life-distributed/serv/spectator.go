@@ -0,0 +1,135 @@
+package serv
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// spectator is a read-only controller watching another controller's session.
+// Messages are queued so that a slow spectator never holds up the simulation.
+type spectator struct {
+	conn     *net.Conn
+	messages chan string
+}
+
+// spectate adds a controller to a running session as a spectator. It receives the current world once,
+// then the same turn diffs and alive cell counts as the owner. It may only ask for the world again with 'y',
+// or stop watching with 'q', which closes its controller as if the session had finished.
+func spectate(conn *net.Conn, reader *bufio.Reader, id string) {
+	s := getSession(id)
+	if s == nil {
+		sendRejected(conn, fmt.Errorf("no session %v", id))
+		(*conn).Close()
+		return
+	}
+
+	sp := &spectator{conn: conn, messages: make(chan string, 1000)}
+
+	// The world is queued under the session mutex, so no diff can be sent between it and the next turn.
+	s.mutex.Lock()
+	if s.state == "Quitting" {
+		s.mutex.Unlock()
+		sendRejected(conn, fmt.Errorf("session %v has finished", id))
+		(*conn).Close()
+		return
+	}
+	sp.messages <- worldString(s.latest.turn, s.latest.world)
+	s.spectators = append(s.spectators, sp)
+	s.mutex.Unlock()
+
+	go func() {
+		for {
+			key, err := reader.ReadString('\n')
+			if err != nil {
+				s.removeSpectator(sp)
+				return
+			}
+			if key == "y\n" {
+				s.mutex.Lock()
+				s.sendSpectator(sp, worldString(s.latest.turn, s.latest.world))
+				s.mutex.Unlock()
+			}
+			if key == "q\n" {
+				s.mutex.Lock()
+				s.sendSpectator(sp, sendCloseProgramCode+strconv.Itoa(s.latest.turn)+"\n")
+				s.dropSpectator(sp)
+				s.mutex.Unlock()
+				return
+			}
+		}
+	}()
+
+	for message := range sp.messages {
+		_, err := fmt.Fprint(*conn, message)
+		if err != nil {
+			s.removeSpectator(sp)
+			break
+		}
+	}
+	(*conn).Close()
+}
+
+// describe answers a controller that is about to spectate with the parameters of a session,
+// in the same format controllers send them in.
+func describe(conn *net.Conn, id string) {
+	s := getSession(id)
+	if s == nil {
+		fmt.Fprint(*conn, "\n")
+		return
+	}
+	p := s.p
+	describeString := strconv.Itoa(p.ImageHeight) + " " + strconv.Itoa(p.ImageWidth) + " " + strconv.Itoa(p.Threads) + " " + strconv.Itoa(p.Turns) + " " + p.Rule + " " + strconv.Itoa(p.AutosaveEvery) + " " + s.id + "\n"
+	fmt.Fprint(*conn, describeString)
+}
+
+// sendSpectators queues a message for every spectator. The session mutex must be held.
+func (s *session) sendSpectators(message string) {
+	for _, sp := range append([]*spectator(nil), s.spectators...) {
+		s.sendSpectator(sp, message)
+	}
+}
+
+// sendSpectator queues a message for a spectator that is still watching. The session mutex must be held.
+func (s *session) sendSpectator(sp *spectator, message string) {
+	for _, other := range s.spectators {
+		if other == sp {
+			select {
+			case sp.messages <- message:
+			default:
+				// The spectator has fallen too far behind to catch up.
+				s.dropSpectator(sp)
+			}
+			return
+		}
+	}
+}
+
+func (s *session) removeSpectator(sp *spectator) {
+	s.mutex.Lock()
+	s.dropSpectator(sp)
+	s.mutex.Unlock()
+}
+
+// dropSpectator stops sending messages to a spectator. The session mutex must be held.
+func (s *session) dropSpectator(sp *spectator) {
+	for i, other := range s.spectators {
+		if other == sp {
+			s.spectators = append(s.spectators[:i], s.spectators[i+1:]...)
+			close(sp.messages)
+			return
+		}
+	}
+}
+
+// closeSpectators tells every spectator the session has finished.
+func (s *session) closeSpectators(turn int) {
+	s.mutex.Lock()
+	s.state = "Quitting"
+	s.sendSpectators(sendCloseProgramCode + strconv.Itoa(turn) + "\n")
+	for len(s.spectators) > 0 {
+		s.dropSpectator(s.spectators[0])
+	}
+	s.mutex.Unlock()
+}
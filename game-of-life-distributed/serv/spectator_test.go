@@ -0,0 +1,60 @@
+package serv_test
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestSpectatorQuit watches a session, quits the spectator with 'q' and checks that its controller closes
+// while the session carries on.
+func TestSpectatorQuit(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100000, Threads: 1, TurnsPerSecond: 50, SessionID: "spectated"}
+	ownerKeys := make(chan rune, 10)
+	watching := make(chan bool)
+	ownerTurns := make(chan int, 1000)
+	ownerDone := make(chan bool)
+	go func() {
+		first := true
+		runEventsWithKeys(p, ownerKeys, func(event gol.Event) {
+			if e, ok := event.(gol.TurnComplete); ok {
+				if first {
+					first = false
+					close(watching)
+				}
+				ownerTurns <- e.CompletedTurns
+			}
+		})
+		close(ownerDone)
+	}()
+	<-watching
+
+	spectatorKeys := make(chan rune, 10)
+	quitting := false
+	quitTurn := 0
+	runEventsWithKeys(gol.Params{Spectate: p.SessionID}, spectatorKeys, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if quitTurn == 0 {
+				quitTurn = e.CompletedTurns
+				spectatorKeys <- 'q'
+			}
+		case gol.StateChange:
+			quitting = e.NewState == gol.Quitting
+		}
+	})
+
+	if !quitting {
+		t.Errorf("expected the spectator to quit")
+	}
+	// The session is still running, so it completes turns after the spectator has gone.
+	for turn := range ownerTurns {
+		if turn > quitTurn+5 {
+			break
+		}
+	}
+	ownerKeys <- 'q'
+	<-ownerDone
+}
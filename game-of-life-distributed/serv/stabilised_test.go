@@ -0,0 +1,61 @@
+package serv_test
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStabilised runs a glider on a 16x16 world, which is back where it started every 64 turns,
+// for a million turns with FinishWhenStable, and checks its final world against 10 turns of it.
+// A history shorter than the period must not find it.
+func TestStabilised(t *testing.T) {
+	runServer()
+
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 10, Threads: 4}
+	expected := runSession(p)
+
+	p.Turns = 1000010
+	p.FinishWhenStable = true
+	var stabilised []gol.Stabilised
+	var final gol.FinalTurnComplete
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.Stabilised:
+			stabilised = append(stabilised, e)
+		case gol.FinalTurnComplete:
+			final = e
+		}
+	})
+
+	if len(stabilised) != 1 || stabilised[0] != (gol.Stabilised{CompletedTurns: 64, Period: 64, FirstTurn: 0}) {
+		t.Errorf("expected a single Stabilised with period 64 at turn 64, got %v", stabilised)
+	}
+	if final.CompletedTurns != p.Turns {
+		t.Errorf("expected the final turn to be %v, got %v", p.Turns, final.CompletedTurns)
+	}
+	if !sameCells(final.Alive, expected) {
+		t.Errorf("final world differs from the world after 10 turns:\n%v", util.AliveCellsToString(final.Alive, expected, p.ImageWidth, p.ImageHeight))
+	}
+
+	p = gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 200, Threads: 4, HistoryDepth: 32}
+	runEvents(p, func(event gol.Event) {
+		switch e := event.(type) {
+		case gol.Stabilised:
+			t.Errorf("a history of 32 turns found period %v", e.Period)
+		}
+	})
+}
+
+// TestStepIntoFinish steps a paused session with FinishWhenStable into the turn it skips to the end from,
+// and checks that it finishes.
+func TestStepIntoFinish(t *testing.T) {
+	runServer()
+
+	// The glider repeats from turn 64 with period 64, so the last turn is the same as turn 64 + 1000008 % 64 = 72.
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 1000072, Threads: 4, TurnsPerSecond: 50, FinishWhenStable: true}
+	if turn := stepLastTurn(t, p, 71); turn != p.Turns {
+		t.Errorf("expected the session to finish at turn %v, got %v", p.Turns, turn)
+	}
+}
@@ -0,0 +1,93 @@
+package serv
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// workerTiming is how a worker spent a turn: computing its strip, then waiting for the slowest worker to finish.
+type workerTiming struct {
+	compute time.Duration
+	wait    time.Duration
+}
+
+// throughput adds up where the time of a session goes between two reports.
+type throughput struct {
+	mutex         sync.Mutex
+	since         time.Time
+	turns         int
+	compute       []time.Duration
+	wait          []time.Duration
+	communication time.Duration
+}
+
+func newThroughput(threads int) *throughput {
+	return &throughput{
+		since:   time.Now(),
+		compute: make([]time.Duration, threads),
+		wait:    make([]time.Duration, threads),
+	}
+}
+
+// addTurn records the timings of the workers during a turn,
+// and how long it took to send the turn to the controller and spectators.
+func (t *throughput) addTurn(timings []workerTiming, communication time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.turns++
+	for i, timing := range timings {
+		t.compute[i] += timing.compute
+		t.wait[i] += timing.wait
+	}
+	t.communication += communication
+}
+
+// restart forgets everything recorded since the last report, e.g. when a session resumes after being paused.
+func (t *throughput) restart() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.reset()
+}
+
+// reset starts a new report. The mutex must be held.
+func (t *throughput) reset() {
+	t.since = time.Now()
+	t.turns = 0
+	for i := range t.compute {
+		t.compute[i] = 0
+		t.wait[i] = 0
+	}
+	t.communication = 0
+}
+
+// report returns the message sent to the controller for the turns since the last report, and starts a new one.
+// The line after the turn holds the turns per second and the average communication time per turn,
+// and the next two lines the average compute and barrier wait time per turn of every worker, all in nanoseconds.
+func (t *throughput) report(turn int) string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	turns := t.turns
+	if turns == 0 {
+		// Avoid dividing by 0. Every total is 0 anyway.
+		turns = 1
+	}
+	turnsPerSecond := float64(t.turns) / time.Since(t.since).Seconds()
+
+	var compute, wait []string
+	for i := range t.compute {
+		compute = append(compute, strconv.FormatInt(int64(t.compute[i])/int64(turns), 10))
+		wait = append(wait, strconv.FormatInt(int64(t.wait[i])/int64(turns), 10))
+	}
+
+	reportString := sendThroughputCode + strconv.Itoa(turn) + "\n" +
+		strconv.FormatFloat(turnsPerSecond, 'f', 2, 64) + " " + strconv.FormatInt(int64(t.communication)/int64(turns), 10) + "\n" +
+		strings.Join(compute, " ") + "\n" +
+		strings.Join(wait, " ") + "\n"
+
+	t.reset()
+	return reportString
+}
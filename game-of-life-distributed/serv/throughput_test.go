@@ -0,0 +1,39 @@
+package serv
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestThroughput checks that the report holds the average times per turn of every worker,
+// and that a report starts the next one from nothing.
+func TestThroughput(t *testing.T) {
+	tp := newThroughput(3)
+	tp.addTurn([]workerTiming{{compute: 10, wait: 30}, {compute: 20, wait: 20}, {compute: 60, wait: 0}}, 100)
+	tp.addTurn([]workerTiming{{compute: 30, wait: 50}, {compute: 40, wait: 40}, {compute: 80, wait: 0}}, 300)
+
+	lines := strings.Split(tp.report(7), "\n")
+	if len(lines) != 6 || lines[0]+"\n" != sendThroughputCode || lines[1] != "7" {
+		t.Fatalf("expected the throughput code, turn and three lines of figures, got %q", lines)
+	}
+	if totals := strings.Fields(lines[2]); len(totals) != 2 || totals[1] != "200" {
+		t.Errorf("expected an average communication time of 200, got %q", lines[2])
+	}
+	if lines[3] != "20 30 70" {
+		t.Errorf("expected average compute times of 20 30 70, got %q", lines[3])
+	}
+	if lines[4] != "40 30 0" {
+		t.Errorf("expected average barrier wait times of 40 30 0, got %q", lines[4])
+	}
+
+	lines = strings.Split(tp.report(8), "\n")
+	if lines[2] != "0.00 0" || lines[3] != "0 0 0" || lines[4] != "0 0 0" {
+		t.Errorf("expected a report without turns to be all zeroes, got %q", lines)
+	}
+
+	tp.addTurn([]workerTiming{{compute: 10}, {compute: 10}, {compute: 10}}, 0)
+	tp.restart()
+	if lines = strings.Split(tp.report(9), "\n"); lines[3] != "0 0 0" {
+		t.Errorf("expected restart to forget the turns recorded, got %q", lines[3])
+	}
+}
@@ -0,0 +1,126 @@
+package serv_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/serv"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const apiAddr = "127.0.0.1:8031"
+
+var startAPI sync.Once
+
+// runAPI starts the control API the first time a test needs it.
+func runAPI() {
+	startAPI.Do(func() {
+		serv.RunAPI(apiAddr)
+		time.Sleep(100 * time.Millisecond)
+	})
+}
+
+// pollStatus requests the status of a session until stop is closed, as a monitoring tool would.
+func pollStatus(id string, stop <-chan bool) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		response, err := http.Get("http://" + apiAddr + "/sessions/" + id)
+		if err == nil {
+			response.Body.Close()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestAliveCellsCount runs several sessions with a fast ticker while their status is being polled,
+// and checks that every AliveCellsCount holds the number of alive cells after the turn it is tagged with.
+// One session also asks for the count after every turn, and another for reports every 100 turns.
+// It is meant to be run with -race.
+func TestAliveCellsCount(t *testing.T) {
+	runServer()
+	runAPI()
+
+	tests := []gol.Params{
+		{ImageWidth: 64, ImageHeight: 64, Turns: 1000, Threads: 1, TickerPeriod: 10 * time.Millisecond},
+		{ImageWidth: 64, ImageHeight: 64, Turns: 1000, Threads: 8, TickerPeriod: 10 * time.Millisecond},
+		{ImageWidth: 256, ImageHeight: 256, Turns: 100, Threads: 3, TickerPeriod: 10 * time.Millisecond},
+		{ImageWidth: 256, ImageHeight: 256, Turns: 100, Threads: 16, TickerPeriod: 10 * time.Millisecond},
+		{ImageWidth: 128, ImageHeight: 128, Turns: 500, Threads: 4, CountEveryTurn: true},
+		{ImageWidth: 64, ImageHeight: 64, Turns: 1000, Threads: 4, TickerEvery: 100},
+	}
+
+	var totalTicks int64
+	var wg sync.WaitGroup
+	for i, p := range tests {
+		p.SessionID = fmt.Sprintf("ticker-%v", i)
+		wg.Add(1)
+		go func(p gol.Params) {
+			defer wg.Done()
+
+			stop := make(chan bool)
+			go pollStatus(p.SessionID, stop)
+			defer close(stop)
+
+			// The cells flipped with 0 completed turns are the initial world.
+			alive := make(map[util.Cell]bool)
+			count := 0
+			counts := []int{0}
+			var ticks []gol.AliveCellsCount
+			runEvents(p, func(event gol.Event) {
+				switch e := event.(type) {
+				case gol.CellFlipped:
+					alive[e.Cell] = !alive[e.Cell]
+					if alive[e.Cell] {
+						count++
+					} else {
+						count--
+					}
+					if e.CompletedTurns == 0 {
+						counts[0] = count
+					}
+				case gol.TurnComplete:
+					counts = append(counts, count)
+				case gol.AliveCellsCount:
+					ticks = append(ticks, e)
+				}
+			})
+
+			atomic.AddInt64(&totalTicks, int64(len(ticks)))
+			if p.CountEveryTurn && len(ticks) < p.Turns {
+				t.Errorf("%v: expected at least %v AliveCellsCount events, got %v", p.SessionID, p.Turns, len(ticks))
+			}
+			if p.TickerEvery > 0 {
+				if len(ticks) != p.Turns/p.TickerEvery {
+					t.Errorf("%v: expected %v AliveCellsCount events, got %v", p.SessionID, p.Turns/p.TickerEvery, len(ticks))
+				}
+				for i, tick := range ticks {
+					if tick.CompletedTurns != (i+1)*p.TickerEvery {
+						t.Errorf("%v: AliveCellsCount %v reported after turn %v", p.SessionID, i+1, tick.CompletedTurns)
+						break
+					}
+				}
+			}
+			for _, tick := range ticks {
+				if tick.CompletedTurns >= len(counts) || tick.CellsCount != counts[tick.CompletedTurns] {
+					t.Errorf("%v: %v alive cells reported after turn %v", p.SessionID, tick.CellsCount, tick.CompletedTurns)
+					return
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	// Small worlds may finish before their ticker fires, but the larger ones never do.
+	if atomic.LoadInt64(&totalTicks) == 0 {
+		t.Error("no AliveCellsCount events")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/serv"
+)
+
+// main is the function called when starting the Game of Life server with 'go run ./server'.
+// Controllers connect to it on port 8030.
+func main() {
+	api := flag.String(
+		"api",
+		"",
+		"Specify an address such as :8081 to serve the json control API on. The API has no authentication, so a bare port is only served on 127.0.0.1; give 0.0.0.0:8081 to expose it to other machines. Defaults to no API.")
+
+	flag.IntVar(
+		&serv.MaxSessions,
+		"maxSessions",
+		serv.MaxSessions,
+		"Specify the number of sessions the server runs at once. Further controllers are turned away. Defaults to 16.")
+
+	flag.Parse()
+
+	serv.RunServ()
+	fmt.Println("Listening for controllers on :8030")
+	if *api != "" {
+		serv.RunAPI(*api)
+		fmt.Println("Serving the control API on", *api)
+	}
+
+	select {}
+}
@@ -0,0 +1,130 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// frameInterval limits how often the terminal is redrawn, as turns can complete much faster than a terminal can draw.
+const frameInterval = 33 * time.Millisecond
+
+// escapeTimeout is how long the rest of an escape sequence is waited for before ESC is taken as a key on its own.
+const escapeTimeout = 50 * time.Millisecond
+
+// Start draws the world in the terminal instead of an SDL window.
+// Keys are read from stdin and the arrow keys pan the view over boards larger than the terminal.
+func Start(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+	restore := util.RawTerminal()
+	defer restore()
+
+	s := NewScreen(p.ImageWidth, p.ImageHeight)
+	pans := make(chan util.Cell, 10)
+	go readKeys(keyPresses, pans)
+
+	lastFrame := time.Now()
+	// redraw fires when a turn has completed since the last frame, so that the screen never stays on an older turn.
+	var redraw <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				s.RenderFrame()
+				s.Destroy()
+				return
+			}
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				s.FlipCell(e.Cell.X, e.Cell.Y)
+			case gol.TurnComplete:
+				if wait := frameInterval - time.Since(lastFrame); wait > 0 {
+					if redraw == nil {
+						redraw = time.After(wait)
+					}
+				} else {
+					s.RenderFrame()
+					lastFrame = time.Now()
+				}
+			default:
+				if len(event.String()) > 0 {
+					s.SetStatus(fmt.Sprintf("Completed Turns %-8v%v", event.GetCompletedTurns(), event))
+				}
+				if _, ok := event.(gol.StateChange); ok {
+					// A paused game completes no more turns, so the latest one is drawn straight away.
+					s.RenderFrame()
+					lastFrame = time.Now()
+					redraw = nil
+				}
+			}
+		case <-redraw:
+			s.RenderFrame()
+			lastFrame = time.Now()
+			redraw = nil
+		case pan := <-pans:
+			s.Pan(pan.X*s.cols/4, pan.Y*s.rows/2)
+		}
+	}
+}
+
+// readKeys forwards key presses to the controller, except for the arrow keys, which pan the view.
+// Arrow keys arrive as the escape sequences ESC [ A to ESC [ D. An ESC that is not followed straight away by the
+// rest of a sequence is dropped, so that it does not hold up the keys after it.
+func readKeys(keyPresses chan<- rune, pans chan<- util.Cell) {
+	runes := make(chan rune, 10)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			key, _, err := reader.ReadRune()
+			if err != nil {
+				close(runes)
+				return
+			}
+			runes <- key
+		}
+	}()
+
+	next := func() (rune, bool) {
+		select {
+		case key, ok := <-runes:
+			return key, ok
+		case <-time.After(escapeTimeout):
+			return 0, false
+		}
+	}
+
+	for key := range runes {
+		if key == '\x1b' {
+			bracket, ok := next()
+			if !ok {
+				continue
+			}
+			if bracket != '[' {
+				key = bracket
+			} else {
+				arrow, _ := next()
+				switch arrow {
+				case 'A':
+					pans <- util.Cell{X: 0, Y: -1}
+				case 'B':
+					pans <- util.Cell{X: 0, Y: 1}
+				case 'C':
+					pans <- util.Cell{X: 1, Y: 0}
+				case 'D':
+					pans <- util.Cell{X: -1, Y: 0}
+				}
+				continue
+			}
+		}
+		if key == '\r' {
+			key = '\n'
+		}
+		if key != ' ' {
+			keyPresses <- key
+		}
+	}
+}
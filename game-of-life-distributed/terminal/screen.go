@@ -0,0 +1,142 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Screen draws the world in a terminal using ANSI escape codes.
+// Every character shows two rows of cells with half block characters, and only characters that changed are redrawn.
+// Boards larger than the terminal are shown through a viewport that can be panned.
+type Screen struct {
+	Width, Height int
+	cols, rows    int
+	viewX, viewY  int
+	world         [][]bool
+	drawn         [][]rune
+	dirty         map[util.Cell]bool
+	status        string
+	out           *bufio.Writer
+}
+
+// NewScreen clears the terminal and prepares it to draw a width x height world.
+func NewScreen(width, height int) *Screen {
+	rows, cols := util.TerminalSize()
+
+	// The last row of the terminal is kept for the status line.
+	rows--
+	if rows > (height+1)/2 {
+		rows = (height + 1) / 2
+	}
+	if cols > width {
+		cols = width
+	}
+
+	world := make([][]bool, height)
+	for i := range world {
+		world[i] = make([]bool, width)
+	}
+	drawn := make([][]rune, rows)
+	for i := range drawn {
+		drawn[i] = make([]rune, cols)
+		for j := range drawn[i] {
+			drawn[i][j] = ' '
+		}
+	}
+
+	s := &Screen{
+		Width:  width,
+		Height: height,
+		cols:   cols,
+		rows:   rows,
+		world:  world,
+		drawn:  drawn,
+		dirty:  make(map[util.Cell]bool),
+		out:    bufio.NewWriter(os.Stdout),
+	}
+
+	// Clear the screen and hide the cursor.
+	fmt.Fprint(s.out, "\x1b[2J\x1b[?25l")
+	s.out.Flush()
+	return s
+}
+
+// Destroy moves the cursor below the board and shows it again.
+func (s *Screen) Destroy() {
+	fmt.Fprintf(s.out, "\x1b[%d;1H\x1b[?25h\n", s.rows+1)
+	s.out.Flush()
+}
+
+// FlipCell changes the state of a cell. It is drawn by the next RenderFrame.
+func (s *Screen) FlipCell(x, y int) {
+	s.world[y][x] = !s.world[y][x]
+	s.dirty[util.Cell{X: x - s.viewX, Y: (y - s.viewY) / 2}] = true
+}
+
+// SetStatus replaces the text shown below the board.
+func (s *Screen) SetStatus(status string) {
+	s.status = status
+	fmt.Fprintf(s.out, "\x1b[%d;1H\x1b[K%s", s.rows+1, s.status)
+	s.out.Flush()
+}
+
+// Pan moves the viewport by the given number of cells, keeping it inside the board, and redraws everything.
+func (s *Screen) Pan(dx, dy int) {
+	s.viewX = clamp(s.viewX+dx, 0, s.Width-s.cols)
+	s.viewY = clamp(s.viewY+dy, 0, s.Height-s.rows*2)
+	for row := 0; row < s.rows; row++ {
+		for col := 0; col < s.cols; col++ {
+			s.dirty[util.Cell{X: col, Y: row}] = true
+		}
+	}
+	s.RenderFrame()
+	s.SetStatus(fmt.Sprintf("Viewing %v,%v of %vx%v", s.viewX, s.viewY, s.Width, s.Height))
+}
+
+// RenderFrame redraws the characters whose cells have changed since the last frame.
+func (s *Screen) RenderFrame() {
+	for position := range s.dirty {
+		row, col := position.Y, position.X
+		if row < 0 || row >= s.rows || col < 0 || col >= s.cols {
+			continue
+		}
+		char := s.glyph(row, col)
+		if char != s.drawn[row][col] {
+			fmt.Fprintf(s.out, "\x1b[%d;%dH%c", row+1, col+1, char)
+			s.drawn[row][col] = char
+		}
+	}
+	s.dirty = make(map[util.Cell]bool)
+	s.out.Flush()
+}
+
+// glyph returns the half block character showing the two cells under a character of the terminal.
+func (s *Screen) glyph(row, col int) rune {
+	x := s.viewX + col
+	y := s.viewY + row*2
+	top := s.world[y][x]
+	bottom := y+1 < s.Height && s.world[y+1][x]
+	switch {
+	case top && bottom:
+		return '█'
+	case top:
+		return '▀'
+	case bottom:
+		return '▄'
+	default:
+		return ' '
+	}
+}
+
+func clamp(x, min, max int) int {
+	if x > max {
+		x = max
+	}
+	if x < min {
+		x = min
+	}
+	return x
+}
@@ -0,0 +1,25 @@
+package util
+
+// CellHash mixes the coordinates of a cell into 64 bits with the splitmix64 finaliser.
+func CellHash(x, y int) uint64 {
+	h := uint64(x)<<32 | uint64(uint32(y))
+	h += 0x9E3779B97F4A7C15
+	h = (h ^ (h >> 30)) * 0xBF58476D1CE4E5B9
+	h = (h ^ (h >> 27)) * 0x94D049BB133111EB
+	return h ^ (h >> 31)
+}
+
+// WorldHash combines the hashes of every alive cell. As they are combined with xor,
+// the hash of the next world is also the hash of the current one xored with the hashes of the flipped cells.
+// The server and the controller both use it, so that the controller can check its copy of the world.
+func WorldHash(world [][]uint8) uint64 {
+	var h uint64
+	for x := range world {
+		for y := range world[x] {
+			if world[x][y] == 255 {
+				h ^= CellHash(x, y)
+			}
+		}
+	}
+	return h
+}
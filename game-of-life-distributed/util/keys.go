@@ -0,0 +1,134 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// RawTerminal switches the terminal on stdin to unbuffered input without echo, so that single key presses
+// can be read without waiting for enter. It returns a function restoring the previous settings.
+// Nothing is changed if stdin is not a terminal.
+func RawTerminal() func() {
+	state, err := stty("-g")
+	if err != nil {
+		return func() {}
+	}
+	_, err = stty("cbreak", "-echo")
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		_, _ = stty(strings.TrimSpace(state))
+	}
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// ReadKeys forwards every key typed on stdin to keyPresses until stdin is closed.
+// Enter is forwarded as '\n', which ends a number typed after 'a'. Other whitespace is skipped
+// so that keys followed by enter also work when stdin is not a terminal.
+func ReadKeys(keyPresses chan<- rune) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		key, _, err := reader.ReadRune()
+		if err != nil {
+			return
+		}
+		if key == '\n' || key == '\r' {
+			keyPresses <- '\n'
+		} else if !unicode.IsSpace(key) {
+			keyPresses <- key
+		}
+	}
+}
+
+// commandKeys are the keys pressed by the commands ReadCommands understands.
+var commandKeys = map[string]rune{
+	"pause":  'p',
+	"resume": 'p',
+	"save":   's',
+	"quit":   'q',
+	"kill":   'k',
+	"record": 'r',
+	"ticker": 't',
+	"faster": '+',
+	"slower": '-',
+	"step":   'n',
+	"back":   'u',
+}
+
+// numberCommands are the commands followed by a number, which is typed after their key and ended with enter.
+var numberCommands = map[string]rune{
+	"advance": 'a',
+	"a":       'a',
+	"jump":    'j',
+	"j":       'j',
+	"world":   'w',
+	"w":       'w',
+}
+
+// ReadCommands reads commands from stdin a line at a time and presses the keys they stand for, until stdin is closed.
+// "advance 500" runs 500 more turns and then pauses, "jump 20" rewinds a paused game by 20 turns,
+// "world 150" saves the world after turn 150 and "break alive<100" adds a breakpoint.
+// A line of a single character presses that key.
+func ReadCommands(keyPresses chan<- rune) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if key, ok := commandKeys[fields[0]]; ok && len(fields) == 1 {
+			keyPresses <- key
+		} else if key, ok := numberCommands[fields[0]]; ok && len(fields) == 2 && isNumber(fields[1]) {
+			keyPresses <- key
+			for _, digit := range fields[1] {
+				keyPresses <- digit
+			}
+			keyPresses <- '\n'
+		} else if fields[0] == "break" && len(fields) == 2 {
+			keyPresses <- 'b'
+			for _, char := range fields[1] {
+				keyPresses <- char
+			}
+			keyPresses <- '\n'
+		} else if len(fields) == 1 && len([]rune(fields[0])) == 1 {
+			keyPresses <- []rune(fields[0])[0]
+		} else {
+			fmt.Println("Commands: advance <turns>, jump <turns>, world <turn>, break <breakpoint>, break clear, pause, resume, save, quit, kill, record, ticker, faster, slower, step, back")
+		}
+	}
+}
+
+func isNumber(s string) bool {
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// TerminalSize returns the number of rows and columns of the terminal on stdin.
+// It returns 24 rows of 80 columns if stdin is not a terminal.
+func TerminalSize() (int, int) {
+	size, err := stty("size")
+	if err != nil {
+		return 24, 80
+	}
+	var rows, cols int
+	_, err = fmt.Sscan(size, &rows, &cols)
+	if err != nil || rows == 0 || cols == 0 {
+		return 24, 80
+	}
+	return rows, cols
+}
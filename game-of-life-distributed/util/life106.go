@@ -0,0 +1,41 @@
+package util
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ReadLife106 reads a pattern stored in the Life 1.06 format, which lists the coordinates of every alive cell.
+// Coordinates may be negative, as Life 1.06 places no bounds on the pattern.
+func ReadLife106(path string) []Cell {
+	data, ioError := ioutil.ReadFile(path)
+	Check(ioError)
+
+	var cells []Cell
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		cell := Cell{}
+		cell.X, ioError = strconv.Atoi(fields[0])
+		Check(ioError)
+		cell.Y, ioError = strconv.Atoi(fields[1])
+		Check(ioError)
+		cells = append(cells, cell)
+	}
+
+	return cells
+}
+
+// WriteLife106 writes a list of alive cells to a file in the Life 1.06 format.
+func WriteLife106(path string, cells []Cell) {
+	var output strings.Builder
+	output.WriteString("#Life 1.06\n")
+	for _, cell := range cells {
+		output.WriteString(strconv.Itoa(cell.X) + " " + strconv.Itoa(cell.Y) + "\n")
+	}
+
+	writeString(path, output.String())
+}
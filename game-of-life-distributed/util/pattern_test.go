@@ -0,0 +1,102 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// patternCells is a glider, a blinker and a block spread over a 16x8 world, touching its edges.
+var patternCells = []Cell{
+	{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2},
+	{X: 7, Y: 4}, {X: 8, Y: 4}, {X: 9, Y: 4},
+	{X: 14, Y: 6}, {X: 15, Y: 6}, {X: 14, Y: 7}, {X: 15, Y: 7},
+}
+
+func sameCells(given, expected []Cell) bool {
+	if len(given) != len(expected) {
+		return false
+	}
+	for _, cell := range given {
+		if !cell.in(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+func tempPath(t *testing.T, name string) (string, func()) {
+	dir, err := ioutil.TempDir("", "gol-patterns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, name), func() { os.RemoveAll(dir) }
+}
+
+// TestRleRoundTrip checks that a world written as RLE reads back to the same cells, size and rule.
+func TestRleRoundTrip(t *testing.T) {
+	path, cleanup := tempPath(t, "world.rle")
+	defer cleanup()
+
+	WriteRle(path, patternCells, 16, 8, "B36/S23")
+	cells, width, height, rule := ReadRle(path)
+
+	if !sameCells(cells, patternCells) {
+		t.Errorf("expected cells %v, got %v", patternCells, cells)
+	}
+	if width != 16 || height != 8 {
+		t.Errorf("expected a 16x8 pattern, got %vx%v", width, height)
+	}
+	if rule != "B36/S23" {
+		t.Errorf("expected rule B36/S23, got %v", rule)
+	}
+}
+
+// TestReadRle checks that a hand written glider with comments and omitted counts is read correctly.
+func TestReadRle(t *testing.T) {
+	path, cleanup := tempPath(t, "glider.rle")
+	defer cleanup()
+
+	err := ioutil.WriteFile(path, []byte("#N Glider\n#C A comment\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"), 0644)
+	Check(err)
+
+	cells, width, height, rule := ReadRle(path)
+	expected := patternCells[:5]
+
+	if !sameCells(cells, expected) {
+		t.Errorf("expected cells %v, got %v", expected, cells)
+	}
+	if width != 3 || height != 3 || rule != "B3/S23" {
+		t.Errorf("expected a 3x3 B3/S23 pattern, got %vx%v %v", width, height, rule)
+	}
+}
+
+// TestPlaintextRoundTrip checks that a world written as plaintext reads back to the same cells and size.
+func TestPlaintextRoundTrip(t *testing.T) {
+	path, cleanup := tempPath(t, "world.cells")
+	defer cleanup()
+
+	WritePlaintext(path, patternCells, 16, 8)
+	cells, width, height := ReadPlaintext(path)
+
+	if !sameCells(cells, patternCells) {
+		t.Errorf("expected cells %v, got %v", patternCells, cells)
+	}
+	if width != 16 || height != 8 {
+		t.Errorf("expected a 16x8 pattern, got %vx%v", width, height)
+	}
+}
+
+// TestLife106RoundTrip checks that a list of cells written as Life 1.06 reads back unchanged.
+func TestLife106RoundTrip(t *testing.T) {
+	path, cleanup := tempPath(t, "world.lif")
+	defer cleanup()
+
+	WriteLife106(path, patternCells)
+	cells := ReadLife106(path)
+
+	if !sameCells(cells, patternCells) {
+		t.Errorf("expected cells %v, got %v", patternCells, cells)
+	}
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ReadPlaintext reads a pattern stored in the plaintext (.cells) format.
+// It returns the alive cells of the pattern together with its width and height.
+func ReadPlaintext(path string) ([]Cell, int, int) {
+	data, ioError := ioutil.ReadFile(path)
+	Check(ioError)
+
+	var cells []Cell
+	width, height := 0, 0
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		for x, char := range line {
+			if char == 'O' || char == '*' {
+				cells = append(cells, Cell{X: x, Y: height})
+			}
+		}
+		if len(line) > width {
+			width = len(line)
+		}
+		height++
+	}
+
+	// A trailing newline does not start another row.
+	if strings.HasSuffix(string(data), "\n") {
+		height--
+	}
+
+	return cells, width, height
+}
+
+// WritePlaintext writes the alive cells of a width x height world to a file in the plaintext (.cells) format.
+func WritePlaintext(path string, cells []Cell, width, height int) {
+	grid := cellsToGrid(cells, width, height)
+
+	var output strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if grid[y][x] {
+				output.WriteString("O")
+			} else {
+				output.WriteString(".")
+			}
+		}
+		output.WriteString("\n")
+	}
+
+	writeString(path, output.String())
+}
+
+// writeString creates the file at path holding the given contents.
+func writeString(path, contents string) {
+	file, ioError := os.Create(path)
+	Check(ioError)
+	defer file.Close()
+
+	_, ioError = file.WriteString(contents)
+	Check(ioError)
+
+	ioError = file.Sync()
+	Check(ioError)
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// ReadPng reads a png image, treating every pixel brighter than threshold as an alive cell.
+// It returns the alive cells together with the width and height of the image.
+func ReadPng(path string, threshold uint8) ([]Cell, int, int) {
+	file, ioError := os.Open(path)
+	Check(ioError)
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	Check(err)
+
+	bounds := img.Bounds()
+	var cells []Cell
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			brightness := color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+			if brightness > threshold {
+				cells = append(cells, Cell{
+					X: x - bounds.Min.X,
+					Y: y - bounds.Min.Y,
+				})
+			}
+		}
+	}
+
+	return cells, bounds.Dx(), bounds.Dy()
+}
+
+// WritePng writes a width x height world to a png image.
+// Every cell is drawn as a scale x scale square so that small worlds are still visible.
+func WritePng(path string, cells []Cell, width, height, scale int) {
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width*scale, height*scale))
+	for _, cell := range cells {
+		for y := cell.Y * scale; y < (cell.Y+1)*scale; y++ {
+			for x := cell.X * scale; x < (cell.X+1)*scale; x++ {
+				img.SetGray(x, y, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+
+	file, ioError := os.Create(path)
+	Check(ioError)
+	defer file.Close()
+
+	ioError = png.Encode(file, img)
+	Check(ioError)
+
+	ioError = file.Sync()
+	Check(ioError)
+}
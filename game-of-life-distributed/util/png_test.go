@@ -0,0 +1,71 @@
+package util
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// TestPngRoundTrip checks that a world written as a png reads back to the same cells and size.
+func TestPngRoundTrip(t *testing.T) {
+	path, cleanup := tempPath(t, "world.png")
+	defer cleanup()
+
+	WritePng(path, patternCells, 16, 8, 1)
+	cells, width, height := ReadPng(path, 127)
+
+	if !sameCells(cells, patternCells) {
+		t.Errorf("expected cells %v, got %v", patternCells, cells)
+	}
+	if width != 16 || height != 8 {
+		t.Errorf("expected a 16x8 image, got %vx%v", width, height)
+	}
+}
+
+// TestPngScale checks that every cell of a scaled png is drawn as a square of alive pixels.
+func TestPngScale(t *testing.T) {
+	path, cleanup := tempPath(t, "world.png")
+	defer cleanup()
+
+	WritePng(path, patternCells, 16, 8, 3)
+	cells, width, height := ReadPng(path, 127)
+
+	var expected []Cell
+	for _, cell := range patternCells {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				expected = append(expected, Cell{X: cell.X*3 + i, Y: cell.Y*3 + j})
+			}
+		}
+	}
+	if !sameCells(cells, expected) {
+		t.Errorf("expected %v alive pixels, got %v", len(expected), len(cells))
+	}
+	if width != 48 || height != 24 {
+		t.Errorf("expected a 48x24 image, got %vx%v", width, height)
+	}
+}
+
+// TestPngThreshold checks that only pixels brighter than the threshold are alive.
+func TestPngThreshold(t *testing.T) {
+	path, cleanup := tempPath(t, "grey.png")
+	defer cleanup()
+
+	img := image.NewGray(image.Rect(0, 0, 3, 1))
+	img.SetGray(0, 0, color.Gray{Y: 50})
+	img.SetGray(1, 0, color.Gray{Y: 100})
+	img.SetGray(2, 0, color.Gray{Y: 200})
+	file, err := os.Create(path)
+	Check(err)
+	Check(png.Encode(file, img))
+	Check(file.Close())
+
+	for threshold, expected := range map[uint8]int{0: 3, 50: 2, 100: 1, 200: 0} {
+		cells, _, _ := ReadPng(path, threshold)
+		if len(cells) != expected {
+			t.Errorf("threshold %v: expected %v alive cells, got %v", threshold, expected, len(cells))
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package util
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ReadRle reads a pattern stored in the RLE format.
+// It returns the alive cells of the pattern together with the width, height and rule from its header.
+// The rule is empty if the header does not specify one.
+func ReadRle(path string) ([]Cell, int, int, string) {
+	data, ioError := ioutil.ReadFile(path)
+	Check(ioError)
+
+	var cells []Cell
+	width, height := 0, 0
+	rule := ""
+
+	x, y := 0, 0
+	count := 0
+	header := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if !header && line[0] == 'x' {
+			header = true
+			for _, field := range strings.Split(line, ",") {
+				pair := strings.SplitN(field, "=", 2)
+				if len(pair) != 2 {
+					continue
+				}
+				key := strings.TrimSpace(pair[0])
+				value := strings.TrimSpace(pair[1])
+				switch key {
+				case "x":
+					width, _ = strconv.Atoi(value)
+				case "y":
+					height, _ = strconv.Atoi(value)
+				case "rule":
+					rule = value
+				}
+			}
+			continue
+		}
+
+		for _, char := range line {
+			switch {
+			case char >= '0' && char <= '9':
+				count = count*10 + int(char-'0')
+				continue
+			case char == '!':
+				return cells, width, height, rule
+			case char == '$':
+				x = 0
+				y += runLength(count)
+			case char == 'b' || char == '.':
+				x += runLength(count)
+			case char == ' ' || char == '\t' || char == '\r':
+			default:
+				// Every other state is treated as alive.
+				for i := 0; i < runLength(count); i++ {
+					cells = append(cells, Cell{X: x, Y: y})
+					x++
+				}
+			}
+			count = 0
+		}
+	}
+
+	return cells, width, height, rule
+}
+
+// runLength returns the length of a run whose count has been omitted if it is 0.
+func runLength(count int) int {
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+// WriteRle writes the alive cells of a width x height world to a file in the RLE format.
+func WriteRle(path string, cells []Cell, width, height int, rule string) {
+	writeString(path, RleString(cells, width, height, rule))
+}
+
+// RleString encodes the alive cells of a width x height world in the RLE format.
+func RleString(cells []Cell, width, height int, rule string) string {
+	grid := cellsToGrid(cells, width, height)
+
+	var tokens []string
+	emptyRows := 0
+	for y := 0; y < height; y++ {
+		var row []string
+		x := 0
+		for x < width {
+			run := 1
+			for x+run < width && grid[y][x+run] == grid[y][x] {
+				run++
+			}
+			// Dead cells at the end of a row are implied.
+			if grid[y][x] || x+run < width {
+				row = append(row, rleToken(run, grid[y][x]))
+			}
+			x += run
+		}
+
+		if len(row) == 0 {
+			emptyRows++
+			continue
+		}
+		if len(tokens) > 0 {
+			tokens = append(tokens, runToken(emptyRows+1, "$"))
+		} else if emptyRows > 0 {
+			tokens = append(tokens, runToken(emptyRows, "$"))
+		}
+		emptyRows = 0
+		tokens = append(tokens, row...)
+	}
+	tokens = append(tokens, "!")
+
+	// Lines of an RLE file should not be longer than 70 characters.
+	var output strings.Builder
+	output.WriteString("x = " + strconv.Itoa(width) + ", y = " + strconv.Itoa(height))
+	if rule != "" {
+		output.WriteString(", rule = " + rule)
+	}
+	output.WriteString("\n")
+	lineLength := 0
+	for _, token := range tokens {
+		if lineLength+len(token) > 70 {
+			output.WriteString("\n")
+			lineLength = 0
+		}
+		output.WriteString(token)
+		lineLength += len(token)
+	}
+	output.WriteString("\n")
+
+	return output.String()
+}
+
+func rleToken(run int, alive bool) string {
+	if alive {
+		return runToken(run, "o")
+	}
+	return runToken(run, "b")
+}
+
+func runToken(run int, tag string) string {
+	if run == 1 {
+		return tag
+	}
+	return strconv.Itoa(run) + tag
+}
+
+// cellsToGrid returns a grid indexed as grid[Y][X] where the alive cells are true.
+func cellsToGrid(cells []Cell, width, height int) [][]bool {
+	grid := make([][]bool, height)
+	for i := range grid {
+		grid[i] = make([]bool, width)
+	}
+	for _, cell := range cells {
+		grid[cell.Y][cell.X] = true
+	}
+	return grid
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseRule reads a rule written as B3/S23 or in the older S/B notation as 23/3, returning for every number of
+// alive neighbours whether a dead cell is born and whether an alive cell survives. An empty rule is Conway's
+// Game of Life. Anything other than the counts 0 to 8, the letters B and S and a single / is an error.
+func ParseRule(ruleString string) (birth, survive [9]bool, err error) {
+	if ruleString == "" {
+		ruleString = "B3/S23"
+	}
+
+	parts := strings.Split(strings.ToUpper(ruleString), "/")
+	if len(parts) > 2 {
+		return birth, survive, fmt.Errorf("invalid rule %v: more than two parts", ruleString)
+	}
+	seen := ""
+	for i, part := range parts {
+		counts, kind := &survive, "S"
+		if strings.HasPrefix(part, "B") || (!strings.HasPrefix(part, "S") && i == 1) {
+			counts, kind = &birth, "B"
+		}
+		if strings.Contains(seen, kind) {
+			return birth, survive, fmt.Errorf("invalid rule %v: %v is given twice", ruleString, kind)
+		}
+		seen += kind
+
+		for _, char := range strings.TrimPrefix(part, kind) {
+			if char < '0' || char > '8' {
+				return birth, survive, fmt.Errorf("invalid rule %v: unexpected %q", ruleString, char)
+			}
+			counts[char-'0'] = true
+		}
+	}
+	return birth, survive, nil
+}
@@ -0,0 +1,58 @@
+package util
+
+import "testing"
+
+// TestParseRule checks rules in B/S and S/B notation and that anything else is rejected.
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule    string
+		birth   string
+		survive string
+		valid   bool
+	}{
+		{"", "3", "23", true},
+		{"B3/S23", "3", "23", true},
+		{"b36/s23", "36", "23", true},
+		{"S23/B36", "36", "23", true},
+		{"23/3", "3", "23", true},
+		{"B2/S", "2", "", true},
+		{"B3", "3", "", true},
+		{"B012345678/S012345678", "012345678", "012345678", true},
+		{"B3/S2x3", "", "", false},
+		{"B9/S23", "", "", false},
+		{"foo", "", "", false},
+		{"B3/S23/B1", "", "", false},
+		{"B3/B23", "", "", false},
+		{"B3 /S23", "", "", false},
+	}
+
+	for _, test := range tests {
+		birth, survive, err := ParseRule(test.rule)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.rule, err)
+			continue
+		}
+		if got := counts(birth); got != test.birth {
+			t.Errorf("%q: expected birth on %q, got %q", test.rule, test.birth, got)
+		}
+		if got := counts(survive); got != test.survive {
+			t.Errorf("%q: expected survival on %q, got %q", test.rule, test.survive, got)
+		}
+	}
+}
+
+func counts(set [9]bool) string {
+	s := ""
+	for n, ok := range set {
+		if ok {
+			s += string(rune('0' + n))
+		}
+	}
+	return s
+}